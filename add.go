@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+var (
+	ErrAlreadyInChain = errors.New("item is already in the chain")
+	ErrNotInChain     = errors.New("item is not in the chain")
+)
+
+func addCommand(client *GhClient, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	after := fs.String("after", "", "Insert the item after this `ref` and the items nested under it.")
+	before := fs.String("before", "", "Insert the item before this `ref`.")
+	position := fs.Int("position", 0, "Insert the item at this 1-based `position` in the chain. Defaults to the end.")
+	name := fs.String("chain", "", "Name of the chain to add to when the body has more than one.")
+	opts := addSyncFlags(fs)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: gh chainlink add [flags] <chain ref> <item ref>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("add needs a chain ref and an item ref")
+	}
+
+	chainRef := getTargetIssue(fs.Args()[:1])
+	if chainRef.Number == 0 {
+		return ErrNoIssueRef
+	}
+	if err := configure(client, fs, opts, chainRef.Repo); err != nil {
+		return err
+	}
+
+	chain, err := loadChain(client, fs.Args()[:1], *name)
+	if err != nil {
+		return err
+	}
+
+	issue := resolveRef(chain.Source.Repo, fs.Arg(1))
+	if issue.Number == 0 {
+		return fmt.Errorf("bad item ref %q", fs.Arg(1))
+	}
+	if chain.Index(issue) >= 0 {
+		return fmt.Errorf("%w: %s", ErrAlreadyInChain, issue.Ref())
+	}
+
+	index, depth, err := insertPosition(*chain, *after, *before, *position)
+	if err != nil {
+		return err
+	}
+
+	// the source may not be one of the items, so it is written as well
+	opts.UpdateSource = true
+	return runSync(client, chain.InsertItem(index, depth, issue), *opts)
+}
+
+// insertPosition returns the index and depth to insert a new item at. Only one
+// of after, before and position should be set, and the end of the chain is used
+// if none are.
+func insertPosition(chain Chain, after, before string, position int) (int, int, error) {
+	set := 0
+	for _, isSet := range []bool{after != "", before != "", position != 0} {
+		set += iif(isSet, 1, 0)
+	}
+	if set > 1 {
+		return 0, 0, errors.New("only one of --after, --before and --position can be given")
+	}
+
+	find := func(ref string) (int, error) {
+		issue := resolveRef(chain.Source.Repo, ref)
+		i := chain.Index(issue)
+		if issue.Number == 0 || i < 0 {
+			return 0, fmt.Errorf("%w: %s", ErrNotInChain, ref)
+		}
+		return i, nil
+	}
+
+	switch {
+	case after != "":
+		i, err := find(after)
+		if err != nil {
+			return 0, 0, err
+		}
+		return chain.subtreeEnd(i), chain.Items[i].Depth, nil
+	case before != "":
+		i, err := find(before)
+		if err != nil {
+			return 0, 0, err
+		}
+		return i, chain.Items[i].Depth, nil
+	case position != 0:
+		if position < 1 || position > len(chain.Items)+1 {
+			return 0, 0, fmt.Errorf("position must be between 1 and %d", len(chain.Items)+1)
+		}
+		// take the depth of the item that is moved down, or the last item when appending
+		i := position - 1
+		switch {
+		case i < len(chain.Items):
+			return i, chain.Items[i].Depth, nil
+		case i > 0:
+			return i, chain.Items[i-1].Depth, nil
+		}
+		return i, 0, nil
+	}
+	return len(chain.Items), 0, nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInsertPosition(t *testing.T) {
+	chain, err := Parse(TestIssue, `<!--chainlink-->
+1. #1
+2. #2
+   - [x] #3
+   - [ ] #4
+3. #5`)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		after, before string
+		position      int
+		wantIndex     int
+		wantDepth     int
+		wantErr       string
+	}{
+		"End":                 {wantIndex: 5, wantDepth: 0},
+		"AfterSkipsNested":    {after: "#2", wantIndex: 4, wantDepth: 0},
+		"AfterNested":         {after: "3", wantIndex: 3, wantDepth: 1},
+		"Before":              {before: "#4", wantIndex: 3, wantDepth: 1},
+		"Position":            {position: 2, wantIndex: 1, wantDepth: 0},
+		"PositionEnd":         {position: 6, wantIndex: 5, wantDepth: 0},
+		"PositionOutOfRange":  {position: 7, wantErr: "position must be between 1 and 6"},
+		"NotInChain":          {after: "#9", wantErr: "item is not in the chain: #9"},
+		"MoreThanOnePosition": {after: "#1", position: 1, wantErr: "only one of --after, --before and --position can be given"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			index, depth, err := insertPosition(*chain, tt.after, tt.before, tt.position)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantIndex, index)
+			assert.Equal(t, tt.wantDepth, depth)
+		})
+	}
+}
+
+func TestChain_InsertItem(t *testing.T) {
+	chain, err := Parse(TestIssue, `<!--chainlink-->
+1. #1
+2. #2
+   - [x] #3
+3. #5`)
+	assert.NoError(t, err)
+
+	other := ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "RoryQ", Name: "other"}, Number: 7}
+	updated := chain.
+		InsertItem(3, 1, repoIssue(4)).
+		InsertItem(4, 0, other).
+		ResetCurrent(TestIssue)
+
+	expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=8e078459 --> 
+1. #1 &larr; you are here 
+2. #2 
+   - [x] #3 
+   - [ ] #4 
+3. https://github.com/RoryQ/other/issues/7 
+4. #5`
+	assert.Equal(t, expected, updated.RenderMarkdown())
+	assert.Len(t, chain.Items, 4, "the original chain is unchanged")
+}
@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"text/template"
@@ -11,25 +14,44 @@ import (
 )
 
 type Chain struct {
+	Name    string
 	Header  string
 	Source  ChainIssue
 	Current ChainIssue
 	Items   []ChainItem
 	Raw     string
+	// SyncedHash is the Hash in the indicator the chain was parsed from, which
+	// is the Hash of the items when it was last synced.
+	SyncedHash string
+
+	template *template.Template
+	mermaid  bool
 }
 
 type ChainItem struct {
 	ChainIssue
-	IsCurrent bool
-	Message   string
-	ItemState ItemState
-	Raw       string
+	// Depth is the nesting level of the item, with 0 for the top level.
+	Depth      int
+	IsCurrent  bool
+	IsAncestor bool
+	Message    string
+	Title      string
+	ItemState  ItemState
+	IssueState IssueState
+	Badge      string
+	Raw        string
 }
 
 const (
-	CurrentIndicator = "&larr; you are here"
+	DefaultCurrentIndicator = "&larr; you are here"
+	AncestorIndicator       = "&darr; you are under here"
+	TitleSeparator          = " — "
 )
 
+// CurrentIndicator marks the item whose body the chain is rendered in. It can
+// be changed with the indicator flag or the current_indicator config.
+var CurrentIndicator = DefaultCurrentIndicator
+
 type ItemState int
 
 const (
@@ -39,6 +61,86 @@ const (
 	Bulleted
 )
 
+// IssueState is the live state of the issue or pull request an item refers to.
+type IssueState string
+
+const (
+	StateUnknown IssueState = ""
+	StateOpen    IssueState = "open"
+	StateDraft   IssueState = "draft"
+	StateMerged  IssueState = "merged"
+	StateClosed  IssueState = "closed"
+)
+
+// BadgeStyle controls how the IssueState is rendered next to each item.
+type BadgeStyle string
+
+const (
+	NoBadges    BadgeStyle = ""
+	EmojiBadges BadgeStyle = "emoji"
+	TextBadges  BadgeStyle = "text"
+)
+
+func (s BadgeStyle) String() string {
+	return string(s)
+}
+
+// Set implements flag.Value.
+func (s *BadgeStyle) Set(value string) error {
+	switch style := BadgeStyle(value); style {
+	case EmojiBadges, TextBadges:
+		*s = style
+		return nil
+	}
+	return fmt.Errorf("unknown badge style %q", value)
+}
+
+// ListStyle is the list point every item is rendered with, overriding the
+// style the items were parsed with.
+type ListStyle string
+
+const (
+	KeepListStyle ListStyle = ""
+	NumberedList  ListStyle = "numbered"
+	BulletedList  ListStyle = "bulleted"
+	Checklist     ListStyle = "checklist"
+)
+
+func (s ListStyle) String() string {
+	return string(s)
+}
+
+// Set implements flag.Value.
+func (s *ListStyle) Set(value string) error {
+	switch style := ListStyle(value); style {
+	case NumberedList, BulletedList, Checklist:
+		*s = style
+		return nil
+	}
+	return fmt.Errorf("unknown list style %q", value)
+}
+
+var emojiBadges = map[IssueState]string{
+	StateOpen:   "🟢",
+	StateDraft:  "⚪",
+	StateMerged: "🟣",
+	StateClosed: "🔴",
+}
+
+// Badge returns the badge for the state in the given style.
+func (s IssueState) Badge(style BadgeStyle) string {
+	if s == StateUnknown {
+		return ""
+	}
+	switch style {
+	case EmojiBadges:
+		return emojiBadges[s]
+	case TextBadges:
+		return "`" + string(s) + "`"
+	}
+	return ""
+}
+
 type ChainIssue struct {
 	Repo          repository.Repository
 	Number        int
@@ -63,15 +165,31 @@ func (i ChainIssue) URL() string {
 	return fmt.Sprint("https://", i.Repo.Host, "/", i.Repo.Owner, "/", i.Repo.Name, "/issues/", i.Number)
 }
 
+// Key returns the issue without the IsPullRequest flag, for use as a map key.
+func (i ChainIssue) Key() ChainIssue {
+	return ChainIssue{Repo: i.Repo, Number: i.Number}
+}
+
+// Ref returns the issue in owner/repo#number form.
+func (i ChainIssue) Ref() string {
+	return fmt.Sprint(i.Repo.Owner, "/", i.Repo.Name, "#", i.Number)
+}
+
 func (i ChainIssue) IsSame(other ChainIssue) bool {
 	return i.Repo == other.Repo && i.Number == other.Number
 }
 
 func (i ChainItem) Render(pointIndex int) string {
+	parts := []string{i.renderListPoint(pointIndex), i.Message}
+	if i.Title != "" {
+		parts[1] += TitleSeparator + i.Title
+	}
+	if i.Badge != "" {
+		parts = append(parts, i.Badge)
+	}
 	rendered := fmt.Sprintln(
-		i.renderListPoint(pointIndex),
-		i.Message,
-		iif(i.IsCurrent, CurrentIndicator, ""))
+		strings.Join(parts, " "),
+		iif(i.IsCurrent, CurrentIndicator, iif(i.IsAncestor, AncestorIndicator, "")))
 	return strings.TrimRight(rendered, " \n")
 }
 
@@ -96,27 +214,267 @@ func iif[T any](cond bool, a, b T) T {
 	return b
 }
 
+// NewChain builds a numbered chain of the issues with the first issue as the source.
+func NewChain(issues []ChainIssue) Chain {
+	chain := Chain{}
+	for _, issue := range issues {
+		chain.Items = append(chain.Items, ChainItem{
+			ChainIssue: issue,
+			Message:    fmt.Sprint("#", issue.Number),
+			ItemState:  Numbered,
+		})
+	}
+	if len(chain.Items) > 0 {
+		chain.Source = chain.Items[0].ChainIssue
+		chain.Current = chain.Source
+		chain.Items[0].IsCurrent = true
+	}
+	return chain
+}
+
 func (c Chain) ResetCurrent(to ChainIssue) Chain {
 	newChain := c
 	newChain.Current = to
 	newChain.Items = []ChainItem{}
 	for _, item := range c.Items {
-		item.IsCurrent = item.ChainIssue.IsSame(to)
+		item.IsCurrent = item.Number != 0 && item.ChainIssue.IsSame(to)
+		item.IsAncestor = false
 		// If the source is different then replace the message with the full url
-		if newChain.Current.Repo != newChain.Source.Repo {
+		if newChain.Current.Repo != newChain.Source.Repo && item.Number != 0 {
 			item.Message = item.URL()
 		}
 		newChain.Items = append(newChain.Items, item)
 	}
+
+	// mark the items the current item is nested under
+	for i, item := range newChain.Items {
+		if !item.IsCurrent {
+			continue
+		}
+		for j, depth := i-1, item.Depth; j >= 0 && depth > 0; j-- {
+			if newChain.Items[j].Depth < depth {
+				newChain.Items[j].IsAncestor = true
+				depth = newChain.Items[j].Depth
+			}
+		}
+	}
+	return newChain
+}
+
+// ListPoint returns the indentation and list point of the item at index i,
+// numbered within its own level.
+func (c Chain) ListPoint(i int) string {
+	indent, index := c.position(i)
+	return strings.Repeat(" ", indent) + c.Items[i].renderListPoint(index)
+}
+
+// ItemLines renders each item indented under its parent.
+func (c Chain) ItemLines() []string {
+	lines := make([]string, len(c.Items))
+	for i, item := range c.Items {
+		indent, index := c.position(i)
+		lines[i] = strings.Repeat(" ", indent) + item.Render(index)
+	}
+	return lines
+}
+
+// position returns the indentation of the item at index i and its index among
+// its siblings. Children are indented to the content of their parent item.
+func (c Chain) position(i int) (indent, index int) {
+	depth := c.Items[i].Depth
+	for j := i - 1; j >= 0; j-- {
+		switch {
+		case c.Items[j].Depth == depth:
+			index++
+		case c.Items[j].Depth < depth:
+			parentIndent, parentIndex := c.position(j)
+			// the checkbox is part of the content of a checklist item
+			point := iif(c.Items[j].ItemState == Checked || c.Items[j].ItemState == Unchecked, "-", c.Items[j].renderListPoint(parentIndex))
+			return parentIndent + len(point) + 1, index
+		}
+	}
+	return 0, index
+}
+
+// WithBadges sets the badge for each item from its IssueState.
+func (c Chain) WithBadges(style BadgeStyle) Chain {
+	newChain := c
+	newChain.Items = []ChainItem{}
+	for _, item := range c.Items {
+		item.Badge = item.IssueState.Badge(style)
+		newChain.Items = append(newChain.Items, item)
+	}
 	return newChain
 }
 
+// WithoutTitles drops the titles read from the body, so they are only rendered
+// when they are refreshed.
+func (c Chain) WithoutTitles() Chain {
+	newChain := c
+	newChain.Items = []ChainItem{}
+	for _, item := range c.Items {
+		item.Title = ""
+		newChain.Items = append(newChain.Items, item)
+	}
+	return newChain
+}
+
+// WithListStyle renders every item with the list style. Checked items stay
+// checked when the style is a checklist.
+func (c Chain) WithListStyle(style ListStyle) Chain {
+	newChain := c
+	newChain.Items = []ChainItem{}
+	for _, item := range c.Items {
+		switch style {
+		case NumberedList:
+			item.ItemState = Numbered
+		case BulletedList:
+			item.ItemState = Bulleted
+		case Checklist:
+			item.ItemState = iif(item.ItemState == Checked, Checked, Unchecked)
+		}
+		newChain.Items = append(newChain.Items, item)
+	}
+	return newChain
+}
+
+// SyncCheckboxes checks the checklist items that are merged or closed and
+// unchecks the rest. Items in other list styles are unchanged.
+func (c Chain) SyncCheckboxes() Chain {
+	newChain := c
+	newChain.Items = []ChainItem{}
+	for _, item := range c.Items {
+		if item.ItemState == Checked || item.ItemState == Unchecked {
+			done := item.IssueState == StateMerged || item.IssueState == StateClosed
+			item.ItemState = iif(done, Checked, Unchecked)
+		}
+		newChain.Items = append(newChain.Items, item)
+	}
+	return newChain
+}
+
+// Index returns the index of the first item that refers to the issue, or -1.
+func (c Chain) Index(issue ChainIssue) int {
+	return slices.IndexFunc(c.Items, func(item ChainItem) bool {
+		return item.Number != 0 && item.ChainIssue.IsSame(issue)
+	})
+}
+
+// subtreeEnd returns the index after the last item nested under the item at index i.
+func (c Chain) subtreeEnd(i int) int {
+	end := i + 1
+	for end < len(c.Items) && c.Items[end].Depth > c.Items[i].Depth {
+		end++
+	}
+	return end
+}
+
+// InsertItem inserts an item for the issue at index with the given depth. The
+// item gets the list point of the closest item at the same depth, unchecked if
+// that is a checklist.
+func (c Chain) InsertItem(index, depth int, issue ChainIssue) Chain {
+	item := ChainItem{
+		ChainIssue: issue,
+		Depth:      depth,
+		Message:    iif(issue.Repo == c.Source.Repo, fmt.Sprint("#", issue.Number), issue.URL()),
+		ItemState:  c.siblingState(index, depth),
+	}
+
+	newChain := c
+	newChain.Items = slices.Insert(slices.Clone(c.Items), index, item)
+	return newChain
+}
+
+// RemoveItem removes the item at index. The items nested under it move up a level.
+func (c Chain) RemoveItem(index int) Chain {
+	newChain := c
+	newChain.Items = slices.Clone(c.Items)
+	for i := index + 1; i < c.subtreeEnd(index); i++ {
+		newChain.Items[i].Depth--
+	}
+	newChain.Items = slices.Delete(newChain.Items, index, index+1)
+	return newChain
+}
+
+// MoveItem moves the item at index and the items nested under it to index of
+// the chain without them, at the given depth.
+func (c Chain) MoveItem(index, to, depth int) Chain {
+	moved := slices.Clone(c.Items[index:c.subtreeEnd(index)])
+	delta := depth - moved[0].Depth
+	for i := range moved {
+		moved[i].Depth += delta
+	}
+
+	newChain := c.withoutSubtree(index)
+	newChain.Items = slices.Insert(newChain.Items, to, moved...)
+	return newChain
+}
+
+// withoutSubtree returns the chain without the item at index and the items nested under it.
+func (c Chain) withoutSubtree(index int) Chain {
+	newChain := c
+	newChain.Items = slices.Delete(slices.Clone(c.Items), index, c.subtreeEnd(index))
+	return newChain
+}
+
+// siblingState returns the list point of the closest item at the depth to
+// index, or Numbered if there is none.
+func (c Chain) siblingState(index, depth int) ItemState {
+	for distance := 0; distance <= len(c.Items); distance++ {
+		for _, i := range []int{index - 1 - distance, index + distance} {
+			if i >= 0 && i < len(c.Items) && c.Items[i].Depth == depth {
+				return iif(c.Items[i].ItemState == Checked, Unchecked, c.Items[i].ItemState)
+			}
+		}
+	}
+	return Numbered
+}
+
+// Contains reports whether the issue is one of the items in the chain.
+func (c Chain) Contains(issue ChainIssue) bool {
+	return slices.ContainsFunc(c.Items, func(item ChainItem) bool {
+		return item.ChainIssue.IsSame(issue)
+	})
+}
+
+const (
+	indicatorTemplate = `{{- if .Header }}{{ println .Header }}{{ end -}}
+<!-- chainlink{{with .Name}} name={{.}}{{end}} generated from {{.Source.URL}}{{with .Hash}} hash={{.}}{{end}} -->`
+	listTemplate = indicatorTemplate + `
+{{- range .ItemLines }} 
+{{ . }} {{- end}}`
+)
+
+// RenderMarkdown renders the chain as it is written to the body of the current
+// item. Templates must have been checked with checkTemplate, as an error
+// rendering one panics.
 func (c Chain) RenderMarkdown() string {
-	templateString := `{{- if .Header }}{{ println .Header }}{{ end -}}
-<!-- chainlink generated from {{.Source.URL}} -->
-{{- range $i, $v :=  .Items }} 
-{{$v.Render $i }} {{- end}}`
+	switch {
+	case c.template != nil:
+		return must(c.renderTemplate())
+	case c.mermaid:
+		// the end indicator keeps the graph part of the chain when it is replaced
+		return fmt.Sprintf("%s\n\n%s\n%s", c.execute(listTemplate), c.renderMermaid(), EndIndicator)
+	}
+	return c.execute(listTemplate)
+}
+
+// Hash identifies the items and their nesting, so a chain that was edited by
+// hand since it was synced can be told apart.
+func (c Chain) Hash() string {
+	h := sha256.New()
+	for _, item := range c.Items {
+		_, _ = fmt.Fprintln(h, item.Depth, strings.ToLower(iif(item.Number != 0, item.Ref(), item.Message)))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
+
+// renderIndicator renders the header and the indicator comment.
+func (c Chain) renderIndicator() string {
+	return c.execute(indicatorTemplate)
+}
 
+func (c Chain) execute(templateString string) string {
 	tmpl := template.Must(template.New("").Parse(templateString))
 	buf := new(bytes.Buffer)
 	must0(tmpl.Execute(buf, c))
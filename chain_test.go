@@ -12,6 +12,8 @@ func TestChainItem_RenderMarkdown(t *testing.T) {
 		IsCurrent  bool
 		Message    string
 		ItemState  ItemState
+		Title      string
+		Badge      string
 		Raw        string
 	}
 	tests := map[string]struct {
@@ -34,6 +36,35 @@ func TestChainItem_RenderMarkdown(t *testing.T) {
 			},
 			want: "- [ ] #123 " + CurrentIndicator,
 		},
+		"CurrentWithBadge": {
+			fields: fields{
+				ChainIssue: TestIssue,
+				Message:    "#123",
+				IsCurrent:  true,
+				Badge:      StateMerged.Badge(EmojiBadges),
+			},
+			want: "- [ ] #123 🟣 " + CurrentIndicator,
+		},
+		"TextBadge": {
+			fields: fields{
+				ChainIssue: TestIssue,
+				Message:    "#123",
+				ItemState:  Numbered,
+				Badge:      StateDraft.Badge(TextBadges),
+			},
+			want: "1. #123 `draft`",
+		},
+		"TitleAndBadge": {
+			fields: fields{
+				ChainIssue: TestIssue,
+				Message:    "#101",
+				ItemState:  Numbered,
+				IsCurrent:  true,
+				Title:      "Add parser for v2 config",
+				Badge:      StateOpen.Badge(EmojiBadges),
+			},
+			want: "1. #101 — Add parser for v2 config 🟢 " + CurrentIndicator,
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -42,6 +73,8 @@ func TestChainItem_RenderMarkdown(t *testing.T) {
 				IsCurrent:  tt.fields.IsCurrent,
 				Message:    tt.fields.Message,
 				ItemState:  tt.fields.ItemState,
+				Title:      tt.fields.Title,
+				Badge:      tt.fields.Badge,
 				Raw:        tt.fields.Raw,
 			}
 			assert.Equalf(t, tt.want, i.Render(0), "Render()")
@@ -73,7 +106,7 @@ func TestChain_RenderMarkdown(t *testing.T) {
 			},
 		}
 
-		expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 --> 
+		expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=3a59c13e --> 
 - [ ] #12 &larr; you are here 
 - [x] #34 
 - [x] #56`
@@ -103,7 +136,7 @@ func TestChain_RenderMarkdown(t *testing.T) {
 			},
 		}
 
-		expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 --> 
+		expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=3a59c13e --> 
 1. #12 &larr; you are here 
 2. #34 
 3. #56`
@@ -133,13 +166,32 @@ func TestChain_RenderMarkdown(t *testing.T) {
 			},
 		}
 
-		expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 --> 
+		expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=3a59c13e --> 
 - #12 &larr; you are here 
 - #34 
 - #56`
 		assert.Equal(t, expected, chain.RenderMarkdown())
 	})
 
+	t.Run("Named", func(t *testing.T) {
+		chain := Chain{
+			Name:    "backend",
+			Source:  TestIssue,
+			Current: TestIssue,
+			Items: []ChainItem{
+				{
+					IsCurrent: true,
+					Message:   "#12",
+					ItemState: Numbered,
+				},
+			},
+		}
+
+		expected := `<!-- chainlink name=backend generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=632f0ec4 --> 
+1. #12 &larr; you are here`
+		assert.Equal(t, expected, chain.RenderMarkdown())
+	})
+
 	t.Run("List With Header", func(t *testing.T) {
 		chain := Chain{
 			Header:  "### PR Chain",
@@ -165,10 +217,110 @@ func TestChain_RenderMarkdown(t *testing.T) {
 		}
 
 		expected := `### PR Chain
-<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 --> 
+<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=3a59c13e --> 
 - #12 &larr; you are here 
 - #34 
 - #56`
 		assert.Equal(t, expected, chain.RenderMarkdown())
 	})
 }
+
+func TestChain_RenderMarkdownNested(t *testing.T) {
+	chain := Chain{
+		Source:  TestIssue,
+		Current: TestIssue,
+		Items: []ChainItem{
+			{ChainIssue: repoIssue(1), Message: "#1", ItemState: Numbered},
+			{ChainIssue: repoIssue(2), Message: "#2", ItemState: Numbered, Depth: 1},
+			{ChainIssue: repoIssue(3), Message: "#3", ItemState: Unchecked, Depth: 2},
+			{ChainIssue: repoIssue(4), Message: "#4", ItemState: Unchecked, Depth: 2},
+			{ChainIssue: repoIssue(5), Message: "#5", ItemState: Numbered, Depth: 1},
+			{Message: "Rollout", ItemState: Numbered},
+			{ChainIssue: repoIssue(6), Message: "#6", ItemState: Bulleted, Depth: 1},
+		},
+	}
+
+	expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=1e2c35ce --> 
+1. #1 &darr; you are under here 
+   1. #2 &darr; you are under here 
+      - [ ] #3 
+      - [ ] #4 &larr; you are here 
+   2. #5 
+2. Rollout 
+   - #6`
+	assert.Equal(t, expected, chain.ResetCurrent(repoIssue(4)).RenderMarkdown())
+}
+
+func TestChainIssue_Ref(t *testing.T) {
+	assert.Equal(t, "RoryQ/gh-chainlink#1", TestIssue.Ref())
+}
+
+func TestNewChain(t *testing.T) {
+	first := ChainIssue{Repo: TestIssue.Repo, Number: 10, IsPullRequest: true}
+	second := ChainIssue{Repo: TestIssue.Repo, Number: 11, IsPullRequest: true}
+
+	chain := NewChain([]ChainIssue{first, second})
+
+	expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/pull/10 hash=f5f452af --> 
+1. #10 &larr; you are here 
+2. #11`
+	assert.Equal(t, first, chain.Source)
+	assert.Equal(t, expected, chain.RenderMarkdown())
+}
+
+func TestChain_SyncCheckboxes(t *testing.T) {
+	chain := Chain{
+		Source:  TestIssue,
+		Current: TestIssue,
+		Items: []ChainItem{
+			{Message: "#12", ItemState: Unchecked, IssueState: StateMerged},
+			{Message: "#34", ItemState: Unchecked, IssueState: StateClosed},
+			{Message: "#56", ItemState: Checked, IssueState: StateOpen},
+			{Message: "#78", ItemState: Numbered, IssueState: StateMerged},
+		},
+	}
+
+	expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=59982cb2 --> 
+- [x] #12 
+- [x] #34 
+- [ ] #56 
+4. #78`
+	assert.Equal(t, expected, chain.SyncCheckboxes().RenderMarkdown())
+}
+
+func TestChain_WithoutTitles(t *testing.T) {
+	chain, err := Parse(TestIssue, "<!--chainlink-->\n1. #12 — Add parser\n2. #34")
+	assert.NoError(t, err)
+	assert.Equal(t, "Add parser", chain.Items[0].Title)
+
+	expected := "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=3e699995 --> \n1. #12 \n2. #34"
+	assert.Equal(t, expected, chain.WithoutTitles().RenderMarkdown())
+}
+
+func TestChain_WithListStyle(t *testing.T) {
+	chain := Chain{
+		Source:  TestIssue,
+		Current: TestIssue,
+		Items: []ChainItem{
+			{Message: "#12", ItemState: Checked},
+			{Message: "#34", ItemState: Numbered},
+			{Message: "#56", ItemState: Bulleted},
+		},
+	}
+
+	tests := map[string]struct {
+		style ListStyle
+		want  string
+	}{
+		"Keep":      {style: KeepListStyle, want: "- [x] #12 \n2. #34 \n- #56"},
+		"Numbered":  {style: NumberedList, want: "1. #12 \n2. #34 \n3. #56"},
+		"Bulleted":  {style: BulletedList, want: "- #12 \n- #34 \n- #56"},
+		"Checklist": {style: Checklist, want: "- [x] #12 \n- [ ] #34 \n- [ ] #56"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			expected := "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=3a59c13e --> \n" + tt.want
+			assert.Equal(t, expected, chain.WithListStyle(tt.style).RenderMarkdown())
+		})
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/config"
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFile     = "chainlink.yml"
+	repoConfigPath = ".github/" + configFile
+)
+
+// Config holds the defaults read from the repo and user configuration files.
+// Flags take precedence over both, and the repo takes precedence over the user.
+type Config struct {
+	CurrentIndicator string        `yaml:"current_indicator"`
+	ListStyle        ListStyle     `yaml:"list_style"`
+	Concurrency      int           `yaml:"concurrency"`
+	Header           string        `yaml:"header"`
+	Insert           Insert        `yaml:"insert"`
+	Badges           BadgeStyle    `yaml:"badges"`
+	Titles           *bool         `yaml:"titles"`
+	SyncCheckboxes   *bool         `yaml:"sync_checkboxes"`
+	Template         string        `yaml:"template"`
+	Mermaid          *bool         `yaml:"mermaid"`
+	OnDiverge        OnDiverge     `yaml:"on_diverge"`
+	MaxWait          time.Duration `yaml:"max_wait"`
+
+	// repoTemplate is the Template read from the repo, when it is set in the
+	// repo config. Templates in the repo config are never read from local paths.
+	repoTemplate *template.Template
+}
+
+// merge returns the config with the fields that are set in other replaced.
+func (c Config) merge(other Config) Config {
+	c.CurrentIndicator = iif(other.CurrentIndicator != "", other.CurrentIndicator, c.CurrentIndicator)
+	c.ListStyle = iif(other.ListStyle != "", other.ListStyle, c.ListStyle)
+	c.Concurrency = iif(other.Concurrency != 0, other.Concurrency, c.Concurrency)
+	c.Header = iif(other.Header != "", other.Header, c.Header)
+	c.Insert = iif(other.Insert != "", other.Insert, c.Insert)
+	c.Badges = iif(other.Badges != "", other.Badges, c.Badges)
+	c.Titles = iif(other.Titles != nil, other.Titles, c.Titles)
+	c.SyncCheckboxes = iif(other.SyncCheckboxes != nil, other.SyncCheckboxes, c.SyncCheckboxes)
+	c.Template = iif(other.Template != "", other.Template, c.Template)
+	c.repoTemplate = iif(other.Template != "", other.repoTemplate, c.repoTemplate)
+	c.Mermaid = iif(other.Mermaid != nil, other.Mermaid, c.Mermaid)
+	c.OnDiverge = iif(other.OnDiverge != "", other.OnDiverge, c.OnDiverge)
+	c.MaxWait = iif(other.MaxWait != 0, other.MaxWait, c.MaxWait)
+	return c
+}
+
+// apply sets the options that were not given as flags from the config.
+func (c Config) apply(opts *syncOptions, fs *flag.FlagSet) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	apply := func(name string, isSet bool, fn func() error) error {
+		if set[name] || !isSet {
+			return nil
+		}
+		if err := fn(); err != nil {
+			return fmt.Errorf("config %s: %w", name, err)
+		}
+		return nil
+	}
+
+	return errors.Join(
+		apply("indicator", c.CurrentIndicator != "", func() error { opts.Indicator = c.CurrentIndicator; return nil }),
+		apply("list-style", c.ListStyle != "", func() error { return opts.ListStyle.Set(string(c.ListStyle)) }),
+		apply("concurrency", c.Concurrency != 0, func() error { opts.Concurrency = c.Concurrency; return nil }),
+		apply("header", c.Header != "", func() error { opts.Header = c.Header; return nil }),
+		apply("insert", c.Insert != "", func() error { return opts.Insert.Set(string(c.Insert)) }),
+		apply("badges", c.Badges != "", func() error { return opts.Badges.Set(string(c.Badges)) }),
+		apply("titles", c.Titles != nil, func() error { opts.Titles = *c.Titles; return nil }),
+		apply("sync-checkboxes", c.SyncCheckboxes != nil, func() error { opts.SyncCheckboxes = *c.SyncCheckboxes; return nil }),
+		apply("template", c.Template != "", func() error {
+			opts.TemplatePath, opts.RepoTemplate = iif(c.repoTemplate == nil, c.Template, ""), c.repoTemplate
+			return nil
+		}),
+		apply("mermaid", c.Mermaid != nil, func() error { opts.Mermaid = *c.Mermaid; return nil }),
+		apply("on-diverge", c.OnDiverge != "", func() error { return opts.OnDiverge.Set(string(c.OnDiverge)) }),
+		apply("max-wait", c.MaxWait != 0, func() error { opts.MaxWait = c.MaxWait; return nil }),
+	)
+}
+
+// loadConfig reads the user config from the gh config dir and merges the repo
+// config over it. The repo config is read from the local checkout when it is
+// the given repo, otherwise from the contents API. When neither config names a
+// template, the repo's .github/chainlink.tmpl is read the same way.
+func loadConfig(client *GhClient, repo repository.Repository) (Config, error) {
+	user, err := readConfigFile(filepath.Join(config.ConfigDir(), configFile))
+	if err != nil {
+		return Config{}, err
+	}
+
+	// the repo files are read from the checkout only when it is the repo
+	var readFile func(path string) ([]byte, error)
+	name := repoConfigPath
+	if root, gitErr := git("rev-parse", "--show-toplevel"); gitErr == nil && client.currentRepo == repo {
+		readFile = func(path string) ([]byte, error) {
+			return os.ReadFile(filepath.Join(root, filepath.FromSlash(path)))
+		}
+	} else if repo != (repository.Repository{}) {
+		readFile = func(path string) ([]byte, error) {
+			return client.getRepoFile(repo, path)
+		}
+		name = repo.Owner + "/" + repo.Name + "/" + repoConfigPath
+	} else {
+		return user, nil
+	}
+
+	return mergeRepoConfig(user, readFile, name)
+}
+
+// mergeRepoConfig merges the repo config read with readFile over the user
+// config. When neither names a template, the repo's .github/chainlink.tmpl is
+// used if it has one.
+func mergeRepoConfig(user Config, readFile func(path string) ([]byte, error), name string) (Config, error) {
+	repoConfig, err := readRepoConfig(readFile, name)
+	if err != nil {
+		return Config{}, err
+	}
+	cfg := user.merge(repoConfig)
+	if cfg.Template != "" {
+		return cfg, nil
+	}
+
+	templatePath := path.Join(".github", templateFile)
+	content, err := readFile(templatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("error reading template %s: %w", templatePath, err)
+	}
+	cfg.Template = templateFile
+	cfg.repoTemplate, err = template.New(templateFile).Parse(string(content))
+	if err != nil {
+		return Config{}, fmt.Errorf("error loading template: %w", err)
+	}
+	return cfg, nil
+}
+
+func readConfigFile(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	return parseConfig(content, path)
+}
+
+// readRepoConfig reads the repo config with readFile, which reads a path from
+// the root of the repo. The template it names is read from the .github dir of
+// the repo too, as the repo may not be the one the command was run in.
+func readRepoConfig(readFile func(path string) ([]byte, error), name string) (Config, error) {
+	content, err := readFile(repoConfigPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return Config{}, nil
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	cfg, err := parseConfig(content, name)
+	if err != nil || cfg.Template == "" {
+		return cfg, err
+	}
+
+	templatePath, err := repoTemplatePath(cfg.Template)
+	if err != nil {
+		return Config{}, fmt.Errorf("error reading %s: %w", name, err)
+	}
+	content, err = readFile(templatePath)
+	if err != nil {
+		return Config{}, fmt.Errorf("error reading template %s: %w", templatePath, err)
+	}
+	cfg.repoTemplate, err = template.New(path.Base(templatePath)).Parse(string(content))
+	if err != nil {
+		return Config{}, fmt.Errorf("error loading template: %w", err)
+	}
+	return cfg, nil
+}
+
+// repoTemplatePath returns the path from the root of the repo of a template
+// named in the repo config, which must be inside the .github dir.
+func repoTemplatePath(name string) (string, error) {
+	cleaned := path.Clean(filepath.ToSlash(name))
+	if path.IsAbs(cleaned) || filepath.IsAbs(name) || strings.HasPrefix(cleaned, "~") || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", fmt.Errorf("template %q must be a path in the .github dir of the repo", name)
+	}
+	return path.Join(".github", cleaned), nil
+}
+
+func parseConfig(content []byte, path string) (Config, error) {
+	cfg := Config{}
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return Config{}, fmt.Errorf("error reading %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// getRepoFile reads the file at path in the default branch of the repo, with
+// an error wrapping os.ErrNotExist if there is none.
+func (c *GhClient) getRepoFile(repo repository.Repository, path string) ([]byte, error) {
+	response := struct {
+		Content string
+	}{}
+	client, err := c.getClient(repo.Host)
+	if err != nil {
+		return nil, err
+	}
+	err = c.withRetry(func() error {
+		return client.Get(fmt.Sprint("repos/", repo.Owner, "/", repo.Name, "/contents/", path), &response)
+	})
+	he := &api.HTTPError{}
+	if errors.As(err, &he) && he.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", path, os.ErrNotExist)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(response.Content)
+}
+
+// configure loads the config for the repo and applies it to the options that
+// were not given as flags.
+func configure(client *GhClient, fs *flag.FlagSet, opts *syncOptions, repo repository.Repository) error {
+	cfg, err := loadConfig(client, repo)
+	if err != nil {
+		return err
+	}
+	if err := cfg.apply(opts, fs); err != nil {
+		return err
+	}
+	if opts.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", opts.Concurrency)
+	}
+	if err := checkHeader(opts.Header); err != nil {
+		return err
+	}
+	if opts.Indicator != "" {
+		CurrentIndicator = opts.Indicator
+	}
+	client.MaxWait = opts.MaxWait
+	return nil
+}
+
+// checkHeader returns an error unless the header is empty or a single markdown
+// heading. Only a heading is found again above the indicator, so any other
+// header would be added again on every sync.
+func checkHeader(header string) error {
+	if header != "" && headerRE.FindString(header) != header {
+		return fmt.Errorf("header must be a markdown heading such as \"## PR Chain\", got %q", header)
+	}
+	return nil
+}
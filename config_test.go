@@ -0,0 +1,254 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseConfig(t *testing.T) {
+	content := `
+current_indicator: "👈"
+list_style: checklist
+concurrency: 2
+header: "## PR Chain"
+insert: top
+badges: emoji
+titles: true
+sync_checkboxes: false
+template: chain.tmpl
+on_diverge: merge
+max_wait: 15m
+`
+	yes, no := true, false
+	expected := Config{
+		CurrentIndicator: "👈",
+		ListStyle:        Checklist,
+		Concurrency:      2,
+		Header:           "## PR Chain",
+		Insert:           InsertTop,
+		Badges:           EmojiBadges,
+		Titles:           &yes,
+		SyncCheckboxes:   &no,
+		Template:         "chain.tmpl",
+		OnDiverge:        DivergeMerge,
+		MaxWait:          15 * time.Minute,
+	}
+	cfg, err := parseConfig([]byte(content), "chainlink.yml")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, cfg)
+
+	_, err = parseConfig([]byte("concurrency: lots"), "chainlink.yml")
+	assert.ErrorContains(t, err, "error reading chainlink.yml")
+}
+
+func TestConfig_merge(t *testing.T) {
+	yes, no := true, false
+	user := Config{CurrentIndicator: "👈", Concurrency: 10, Badges: TextBadges, Titles: &yes}
+	repo := Config{Badges: EmojiBadges, Titles: &no, Insert: InsertTop}
+
+	expected := Config{CurrentIndicator: "👈", Concurrency: 10, Badges: EmojiBadges, Titles: &no, Insert: InsertTop}
+	assert.Equal(t, expected, user.merge(repo))
+}
+
+func TestConfig_apply(t *testing.T) {
+	yes := true
+	cfg := Config{
+		ListStyle: BulletedList,
+		Header:    "## PR Chain",
+		Badges:    EmojiBadges,
+		Titles:    &yes,
+	}
+
+	tests := map[string]struct {
+		cfg     Config
+		args    []string
+		want    syncOptions
+		wantErr string
+	}{
+		"ConfigOnly": {
+			cfg:  cfg,
+			want: syncOptions{ListStyle: BulletedList, Header: "## PR Chain", Badges: EmojiBadges, Titles: true, Concurrency: 5, Insert: InsertBottom, OnDiverge: DivergeStop, MaxWait: defaultMaxWait},
+		},
+		"FlagsOverrideConfig": {
+			cfg:  cfg,
+			args: []string{"--badges", "text", "--titles=false", "--concurrency", "1"},
+			want: syncOptions{ListStyle: BulletedList, Header: "## PR Chain", Badges: TextBadges, Concurrency: 1, Insert: InsertBottom, OnDiverge: DivergeStop, MaxWait: defaultMaxWait},
+		},
+		"OnDiverge": {
+			cfg:  Config{OnDiverge: DivergeMerge},
+			want: syncOptions{Concurrency: 5, Insert: InsertBottom, OnDiverge: DivergeMerge, MaxWait: defaultMaxWait},
+		},
+		"MaxWait": {
+			cfg:  Config{MaxWait: 10 * time.Minute},
+			want: syncOptions{Concurrency: 5, Insert: InsertBottom, OnDiverge: DivergeStop, MaxWait: 10 * time.Minute},
+		},
+		"MaxWaitFlag": {
+			cfg:  Config{MaxWait: 10 * time.Minute},
+			args: []string{"--max-wait", "30s"},
+			want: syncOptions{Concurrency: 5, Insert: InsertBottom, OnDiverge: DivergeStop, MaxWait: 30 * time.Second},
+		},
+		"InvalidValue": {
+			cfg:     Config{ListStyle: "table"},
+			wantErr: `config list-style: unknown list style "table"`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet(name, flag.ContinueOnError)
+			opts := addSyncFlags(fs)
+			assert.NoError(t, fs.Parse(tt.args))
+
+			err := tt.cfg.apply(opts, fs)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, *opts)
+		})
+	}
+}
+
+func TestCheckHeader(t *testing.T) {
+	tests := map[string]struct {
+		header  string
+		wantErr bool
+	}{
+		"Empty":         {header: ""},
+		"Heading":       {header: "## PR Chain"},
+		"IndentedLevel": {header: "   ###### Stack"},
+		"Bold":          {header: "**Stack**", wantErr: true},
+		"NoSpace":       {header: "##Stack", wantErr: true},
+		"TwoLines":      {header: "## Stack\nMore", wantErr: true},
+		"TextBefore":    {header: "Intro\n## Stack", wantErr: true},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkHeader(tt.header)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "header must be a markdown heading")
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestReadRepoConfig(t *testing.T) {
+	tests := map[string]struct {
+		files        map[string]string
+		wantTemplate string
+		wantErr      string
+	}{
+		"NoConfig": {},
+		"Template": {
+			files: map[string]string{
+				".github/chainlink.yml":     "template: chains/table.tmpl",
+				".github/chains/table.tmpl": "{{ range .Items }}{{ .Ref }}{{ end }}",
+			},
+			wantTemplate: "table.tmpl",
+		},
+		"AbsolutePath": {
+			files:   map[string]string{".github/chainlink.yml": "template: /etc/passwd"},
+			wantErr: `error reading chainlink.yml: template "/etc/passwd" must be a path in the .github dir of the repo`,
+		},
+		"HomePath": {
+			files:   map[string]string{".github/chainlink.yml": "template: ~/.config/gh/hosts.yml"},
+			wantErr: `error reading chainlink.yml: template "~/.config/gh/hosts.yml" must be a path in the .github dir of the repo`,
+		},
+		"ParentPath": {
+			files:   map[string]string{".github/chainlink.yml": "template: chains/../../secret.tmpl"},
+			wantErr: `error reading chainlink.yml: template "chains/../../secret.tmpl" must be a path in the .github dir of the repo`,
+		},
+		"MissingTemplate": {
+			files:   map[string]string{".github/chainlink.yml": "template: table.tmpl"},
+			wantErr: "error reading template .github/table.tmpl: file does not exist",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			readFile := func(path string) ([]byte, error) {
+				content, ok := tt.files[path]
+				if !ok {
+					return nil, os.ErrNotExist
+				}
+				return []byte(content), nil
+			}
+
+			cfg, err := readRepoConfig(readFile, "chainlink.yml")
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			if tt.wantTemplate == "" {
+				assert.Nil(t, cfg.repoTemplate)
+				return
+			}
+			assert.Equal(t, tt.wantTemplate, cfg.repoTemplate.Name())
+
+			// the repo template is used instead of a local path
+			fs := flag.NewFlagSet(name, flag.ContinueOnError)
+			opts := addSyncFlags(fs)
+			assert.NoError(t, Config{}.merge(cfg).apply(opts, fs))
+			assert.Empty(t, opts.TemplatePath)
+			assert.Equal(t, cfg.repoTemplate, opts.RepoTemplate)
+		})
+	}
+}
+
+func TestMergeRepoConfig(t *testing.T) {
+	tests := map[string]struct {
+		user         Config
+		files        map[string]string
+		wantTemplate string
+		wantPath     string
+	}{
+		"NoTemplate": {},
+		"DefaultTemplate": {
+			files:        map[string]string{".github/chainlink.tmpl": "{{ range .Items }}{{ .Ref }}{{ end }}"},
+			wantTemplate: "chainlink.tmpl",
+		},
+		"UserTemplateOverDefault": {
+			user:     Config{Template: "mine.tmpl"},
+			files:    map[string]string{".github/chainlink.tmpl": "{{ range .Items }}{{ .Ref }}{{ end }}"},
+			wantPath: "mine.tmpl",
+		},
+		"RepoTemplateOverDefault": {
+			files: map[string]string{
+				".github/chainlink.yml":  "template: table.tmpl",
+				".github/table.tmpl":     "{{ range .Items }}{{ .Ref }}{{ end }}",
+				".github/chainlink.tmpl": "{{ range .Items }}{{ .Ref }}{{ end }}",
+			},
+			wantTemplate: "table.tmpl",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			readFile := func(path string) ([]byte, error) {
+				content, ok := tt.files[path]
+				if !ok {
+					return nil, os.ErrNotExist
+				}
+				return []byte(content), nil
+			}
+
+			cfg, err := mergeRepoConfig(tt.user, readFile, "chainlink.yml")
+			assert.NoError(t, err)
+
+			fs := flag.NewFlagSet(name, flag.ContinueOnError)
+			opts := addSyncFlags(fs)
+			assert.NoError(t, cfg.apply(opts, fs))
+			assert.Equal(t, tt.wantPath, opts.TemplatePath)
+			if tt.wantTemplate == "" {
+				assert.Nil(t, opts.RepoTemplate)
+				return
+			}
+			assert.Equal(t, tt.wantTemplate, opts.RepoTemplate.Name())
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/cli/go-gh/v2"
+)
+
+var ErrNoPullRequest = errors.New("no open pull request found for branch")
+
+type branchPull struct {
+	Number      int    `json:"number"`
+	Url         string `json:"url"`
+	HeadRefName string `json:"headRefName"`
+	BaseRefName string `json:"baseRefName"`
+}
+
+func createCommand(client *GhClient, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	name := fs.String("name", "", "Name of the chain, to keep it apart from other chains in the same bodies.")
+	opts := addSyncFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if !client.InGitRepo() {
+		return errors.New("create must be run from inside a git repository")
+	}
+	if err := configure(client, fs, opts, client.currentRepo); err != nil {
+		return err
+	}
+
+	pulls, err := findBranchStack(git, ghExec)
+	if err != nil {
+		return err
+	}
+
+	chain := NewChain(issuesFromPulls(client, pulls)).WithListStyle(opts.ListStyle)
+	chain.Header = opts.Header
+	chain.Name = *name
+
+	// the bottom pull request is the source and one of the items, so the sync writes it with the rest
+	return runSync(client, chain, *opts)
+}
+
+// runner runs a command with args and returns its trimmed output.
+type runner func(args ...string) (string, error)
+
+// findBranchStack walks down from the current branch following the base branch of
+// each open pull request while the base is another local branch. The pulls are
+// returned bottom of the stack first.
+func findBranchStack(git, gh runner) ([]branchPull, error) {
+	branch, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	branches, err := git("for-each-ref", "--format=%(refname:short)", "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	localBranches := strings.Split(branches, "\n")
+
+	var pulls []branchPull
+	for slices.Contains(localBranches, branch) {
+		pull, err := pullForBranch(gh, branch)
+		if errors.Is(err, ErrNoPullRequest) && len(pulls) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.ContainsFunc(pulls, func(p branchPull) bool { return p.Number == pull.Number }) {
+			return nil, fmt.Errorf("branch %s is based on itself", branch)
+		}
+
+		pulls = append(pulls, pull)
+		branch = pull.BaseRefName
+	}
+
+	slices.Reverse(pulls)
+	return pulls, nil
+}
+
+func pullForBranch(gh runner, branch string) (branchPull, error) {
+	out, err := gh("pr", "list",
+		"--head", branch,
+		"--state", "open",
+		"--json", "number,url,headRefName,baseRefName",
+	)
+	if err != nil {
+		return branchPull{}, err
+	}
+
+	var pulls []branchPull
+	if err := json.Unmarshal([]byte(out), &pulls); err != nil {
+		return branchPull{}, err
+	}
+	if len(pulls) == 0 {
+		return branchPull{}, fmt.Errorf("%w %s", ErrNoPullRequest, branch)
+	}
+	return pulls[0], nil
+}
+
+func issuesFromPulls(client *GhClient, pulls []branchPull) []ChainIssue {
+	var issues []ChainIssue
+	for _, pull := range pulls {
+		issue := issueFromMessage(client.currentRepo, pull.Url)
+		issue.IsPullRequest = true
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+func git(args ...string) (string, error) {
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %w", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+func ghExec(args ...string) (string, error) {
+	stdOut, stdErr, err := gh.Exec(args...)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", err, stdErr.String())
+	}
+	return strings.TrimSpace(stdOut.String()), nil
+}
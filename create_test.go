@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuesFromPulls(t *testing.T) {
+	client := &GhClient{currentRepo: TestIssue.Repo}
+	pulls := []branchPull{
+		{Number: 10, Url: "https://github.com/RoryQ/gh-chainlink/pull/10", HeadRefName: "feature-1", BaseRefName: "main"},
+		{Number: 11, Url: "https://github.com/RoryQ/gh-chainlink/pull/11", HeadRefName: "feature-2", BaseRefName: "feature-1"},
+	}
+
+	expected := []ChainIssue{
+		{Repo: TestIssue.Repo, Number: 10, IsPullRequest: true},
+		{Repo: repository.Repository{Host: "github.com", Owner: "RoryQ", Name: "gh-chainlink"}, Number: 11, IsPullRequest: true},
+	}
+	assert.Equal(t, expected, issuesFromPulls(client, pulls))
+}
+
+// fakeRunner returns the output for the first arg, or for the value of --head.
+func fakeRunner(outputs map[string]string) runner {
+	return func(args ...string) (string, error) {
+		key := args[0]
+		if i := slices.Index(args, "--head"); i >= 0 {
+			key = args[i+1]
+		}
+		out, ok := outputs[key]
+		if !ok {
+			return "", fmt.Errorf("unexpected command %v", args)
+		}
+		return out, nil
+	}
+}
+
+func TestFindBranchStack(t *testing.T) {
+	pullJSON := func(number int, head, base string) string {
+		return fmt.Sprintf(`[{"number":%d,"url":"https://github.com/RoryQ/gh-chainlink/pull/%d","headRefName":%q,"baseRefName":%q}]`, number, number, head, base)
+	}
+	branches := "main\nfeature-1\nfeature-2\nfeature-3"
+
+	tests := map[string]struct {
+		current string
+		pulls   map[string]string
+		want    []int
+		wantErr error
+	}{
+		"Stack": {
+			current: "feature-3",
+			pulls: map[string]string{
+				"feature-1": pullJSON(1, "feature-1", "main"),
+				"feature-2": pullJSON(2, "feature-2", "feature-1"),
+				"feature-3": pullJSON(3, "feature-3", "feature-2"),
+				"main":      "[]",
+			},
+			want: []int{1, 2, 3},
+		},
+		"StopsAtBranchWithoutPull": {
+			current: "feature-3",
+			pulls: map[string]string{
+				"feature-1": "[]",
+				"feature-2": pullJSON(2, "feature-2", "feature-1"),
+				"feature-3": pullJSON(3, "feature-3", "feature-2"),
+			},
+			want: []int{2, 3},
+		},
+		"StopsAtRemoteBase": {
+			current: "feature-2",
+			pulls: map[string]string{
+				"feature-2": pullJSON(2, "feature-2", "release"),
+			},
+			want: []int{2},
+		},
+		"CurrentBranchWithoutPull": {
+			current: "feature-1",
+			pulls:   map[string]string{"feature-1": "[]"},
+			wantErr: ErrNoPullRequest,
+		},
+		"Cycle": {
+			current: "feature-1",
+			pulls: map[string]string{
+				"feature-1": pullJSON(1, "feature-1", "feature-2"),
+				"feature-2": pullJSON(2, "feature-2", "feature-1"),
+			},
+			wantErr: errors.New("branch feature-1 is based on itself"),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			git := fakeRunner(map[string]string{"rev-parse": tt.current, "for-each-ref": branches})
+			pulls, err := findBranchStack(git, fakeRunner(tt.pulls))
+			if tt.wantErr != nil {
+				assert.ErrorContains(t, err, tt.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+
+			var numbers []int
+			for _, pull := range pulls {
+				numbers = append(numbers, pull.Number)
+			}
+			assert.Equal(t, tt.want, numbers)
+		})
+	}
+}
+
+func TestPullForBranch(t *testing.T) {
+	gh := fakeRunner(map[string]string{
+		"feature-1": `[{"number":1,"url":"https://github.com/RoryQ/gh-chainlink/pull/1","headRefName":"feature-1","baseRefName":"main"},{"number":5}]`,
+		"feature-2": "[]",
+	})
+
+	pull, err := pullForBranch(gh, "feature-1")
+	assert.NoError(t, err)
+	assert.Equal(t, branchPull{Number: 1, Url: "https://github.com/RoryQ/gh-chainlink/pull/1", HeadRefName: "feature-1", BaseRefName: "main"}, pull)
+
+	_, err = pullForBranch(gh, "feature-2")
+	assert.ErrorIs(t, err, ErrNoPullRequest)
+
+	_, err = pullForBranch(gh, "feature-3")
+	assert.ErrorContains(t, err, "unexpected command")
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const diffContextLines = 3
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// UnifiedDiff returns a unified diff of the lines in a and b, or an empty
+// string if they are equal.
+func UnifiedDiff(fromName, toName, a, b string) string {
+	if a == b {
+		return ""
+	}
+
+	ops := diffLines(strings.Split(a, "\n"), strings.Split(b, "\n"))
+
+	sb := new(strings.Builder)
+	_, _ = fmt.Fprintf(sb, "--- %s\n+++ %s\n", fromName, toName)
+	for _, h := range diffHunks(ops) {
+		_, _ = fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(h.fromLine, h.fromCount), hunkRange(h.toLine, h.toCount))
+		for _, op := range h.ops {
+			_, _ = fmt.Fprintf(sb, "%c%s\n", op.kind, op.line)
+		}
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// diffLines computes the line operations to turn a into b using the longest
+// common subsequence. Bodies are small so the quadratic table is fine.
+func diffLines(a, b []string) []diffOp {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		ops = append(ops, diffOp{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		ops = append(ops, diffOp{'+', b[j]})
+	}
+	return ops
+}
+
+type diffHunk struct {
+	fromLine, fromCount int
+	toLine, toCount     int
+	ops                 []diffOp
+}
+
+func diffHunks(ops []diffOp) []diffHunk {
+	var hunks []diffHunk
+
+	for start := 0; start < len(ops); {
+		// find the next change
+		for start < len(ops) && ops[start].kind == ' ' {
+			start++
+		}
+		if start == len(ops) {
+			break
+		}
+
+		// extend the hunk while changes are within two contexts of each other
+		end := start
+		for unchanged := 0; end < len(ops) && unchanged <= 2*diffContextLines; end++ {
+			if ops[end].kind == ' ' {
+				unchanged++
+			} else {
+				unchanged = 0
+			}
+		}
+		for end > start && ops[end-1].kind == ' ' {
+			end--
+		}
+
+		from := max(start-diffContextLines, 0)
+		to := min(end+diffContextLines, len(ops))
+		h := diffHunk{ops: ops[from:to]}
+		h.fromLine, h.toLine = lineNumbersAt(ops, from)
+		for _, op := range h.ops {
+			if op.kind != '+' {
+				h.fromCount++
+			}
+			if op.kind != '-' {
+				h.toCount++
+			}
+		}
+		hunks = append(hunks, h)
+		start = to
+	}
+
+	return hunks
+}
+
+// lineNumbersAt returns the one-based line numbers in each side at ops[index].
+func lineNumbersAt(ops []diffOp, index int) (from, to int) {
+	from, to = 1, 1
+	for _, op := range ops[:index] {
+		if op.kind != '+' {
+			from++
+		}
+		if op.kind != '-' {
+			to++
+		}
+	}
+	return from, to
+}
+
+func hunkRange(line, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", line-1)
+	}
+	if count == 1 {
+		return fmt.Sprint(line)
+	}
+	return fmt.Sprintf("%d,%d", line, count)
+}
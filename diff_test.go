@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := map[string]struct {
+		a    string
+		b    string
+		want string
+	}{
+		"Equal": {
+			a:    "one\ntwo",
+			b:    "one\ntwo",
+			want: "",
+		},
+		"ChangedLine": {
+			a: "# Description\n## PR Chain\n<!--chainlink-->\n1. #1\n2. #2",
+			b: "# Description\n## PR Chain\n<!--chainlink-->\n1. #1 &larr; you are here\n2. #2",
+			want: `--- a
++++ b
+@@ -1,5 +1,5 @@
+ # Description
+ ## PR Chain
+ <!--chainlink-->
+-1. #1
++1. #1 &larr; you are here
+ 2. #2`,
+		},
+		"Appended": {
+			a: "Some Text.",
+			b: "Some Text.\n<!--chainlink-->\n1. #1",
+			want: `--- a
++++ b
+@@ -1 +1,3 @@
+ Some Text.
++<!--chainlink-->
++1. #1`,
+		},
+		"SeparateHunks": {
+			a: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12",
+			b: "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve",
+			want: `--- a
++++ b
+@@ -1,4 +1,4 @@
+-1
++one
+ 2
+ 3
+ 4
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-12
++twelve`,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, UnifiedDiff("a", "b", tt.a, tt.b))
+		})
+	}
+}
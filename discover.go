@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+)
+
+// pullLister reads pull requests.
+type pullLister interface {
+	GetPull(issue ChainIssue) (PullResponse, error)
+	ListOpenPulls(repo repository.Repository, head, base string) ([]PullResponse, error)
+}
+
+// DiscoverChain builds a chain by following pull request base branches. Below
+// the starting pull request is the pull request whose head is its base, and
+// above it is the pull request whose base is its head.
+func DiscoverChain(client pullLister, start ChainIssue) (*Chain, error) {
+	pull, err := client.GetPull(start)
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving pull request %d: %w", start.Number, err)
+	}
+
+	start.IsPullRequest = true
+	issues := []ChainIssue{start}
+	seen := func(number int) bool {
+		return slices.ContainsFunc(issues, func(i ChainIssue) bool { return i.Number == number })
+	}
+
+	// walk down the stack
+	for base := pull.Base.Ref; ; {
+		pulls, err := client.ListOpenPulls(start.Repo, base, "")
+		if err != nil {
+			return nil, err
+		}
+		if len(pulls) == 0 || seen(pulls[0].Number) {
+			break
+		}
+		issues = slices.Insert(issues, 0, ChainIssue{Repo: start.Repo, Number: pulls[0].Number, IsPullRequest: true})
+		base = pulls[0].Base.Ref
+	}
+
+	// walk up the stack
+	for head := pull.Head.Ref; ; {
+		pulls, err := client.ListOpenPulls(start.Repo, "", head)
+		if err != nil {
+			return nil, err
+		}
+		if len(pulls) == 0 || seen(pulls[0].Number) {
+			break
+		}
+		if len(pulls) > 1 {
+			slog.Warn("multiple pull requests based on branch, using the first", "branch", head, "number", pulls[0].Number)
+		}
+		issues = append(issues, ChainIssue{Repo: start.Repo, Number: pulls[0].Number, IsPullRequest: true})
+		head = pulls[0].Head.Ref
+	}
+
+	chain := NewChain(issues)
+	return &chain, nil
+}
+
+// generatedSource returns the source that the first unnamed chain in the body
+// was generated from, so a rediscovered chain replaces it even after its bottom
+// pull request was merged.
+func generatedSource(current ChainIssue, body string) (ChainIssue, bool) {
+	for _, block := range parseDocument(body).chains() {
+		if indicator := parseIndicator(block.Indicator.Raw); indicator.Name == "" && indicator.Source != "" {
+			return indicator.source(current), true
+		}
+	}
+	return ChainIssue{}, false
+}
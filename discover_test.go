@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakePulls is a pullLister over a fixed set of open pull requests.
+type fakePulls []PullResponse
+
+func (f fakePulls) GetPull(issue ChainIssue) (PullResponse, error) {
+	for _, pull := range f {
+		if pull.Number == issue.Number {
+			return pull, nil
+		}
+	}
+	return PullResponse{}, errors.New("not found")
+}
+
+func (f fakePulls) ListOpenPulls(_ repository.Repository, head, base string) ([]PullResponse, error) {
+	var pulls []PullResponse
+	for _, pull := range f {
+		if (head == "" || pull.Head.Ref == head) && (base == "" || pull.Base.Ref == base) {
+			pulls = append(pulls, pull)
+		}
+	}
+	return pulls, nil
+}
+
+func TestDiscoverChain(t *testing.T) {
+	pull := func(number int, head, base string) PullResponse {
+		return PullResponse{Number: number, Head: BranchRef{Ref: head}, Base: BranchRef{Ref: base}}
+	}
+	stack := fakePulls{
+		pull(1, "feature-1", "main"),
+		pull(2, "feature-2", "feature-1"),
+		pull(3, "feature-3", "feature-2"),
+	}
+
+	tests := map[string]struct {
+		pulls   fakePulls
+		start   int
+		want    []int
+		wantErr bool
+	}{
+		"FromBottom": {
+			pulls: stack,
+			start: 1,
+			want:  []int{1, 2, 3},
+		},
+		"FromMiddle": {
+			pulls: stack,
+			start: 2,
+			want:  []int{1, 2, 3},
+		},
+		"FromTop": {
+			pulls: stack,
+			start: 3,
+			want:  []int{1, 2, 3},
+		},
+		"NotStacked": {
+			pulls: fakePulls{pull(1, "feature-1", "main"), pull(2, "feature-2", "main")},
+			start: 2,
+			want:  []int{2},
+		},
+		"FirstOfSeveralAbove": {
+			pulls: append(stack[:2:2], pull(4, "feature-4", "feature-1")),
+			start: 1,
+			want:  []int{1, 2},
+		},
+		"Cycle": {
+			pulls: fakePulls{pull(1, "feature-1", "feature-2"), pull(2, "feature-2", "feature-1")},
+			start: 1,
+			want:  []int{2, 1},
+		},
+		"NotAPullRequest": {
+			pulls:   stack,
+			start:   9,
+			wantErr: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			chain, err := DiscoverChain(tt.pulls, repoIssue(tt.start))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			var numbers []int
+			for _, item := range chain.Items {
+				assert.True(t, item.IsPullRequest)
+				numbers = append(numbers, item.Number)
+			}
+			assert.Equal(t, tt.want, numbers)
+			assert.Equal(t, tt.want[0], chain.Source.Number, "the bottom pull request is the source")
+			assert.True(t, chain.Contains(repoIssue(tt.start)))
+		})
+	}
+}
+
+func TestGeneratedSource(t *testing.T) {
+	current := repoIssue(2)
+	tests := map[string]struct {
+		body   string
+		want   ChainIssue
+		wantOk bool
+	}{
+		"Generated": {
+			body:   "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/pull/1 -->\n1. #1\n2. #2",
+			want:   ChainIssue{Repo: TestIssue.Repo, Number: 1, IsPullRequest: true},
+			wantOk: true,
+		},
+		"WrittenByHand": {
+			body: "<!--chainlink-->\n1. #1\n2. #2",
+		},
+		"Named": {
+			body: "<!-- chainlink name=rollout generated from https://github.com/RoryQ/gh-chainlink/pull/1 -->\n1. #1",
+		},
+		"NoChain": {
+			body: "Description",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			source, ok := generatedSource(current, tt.body)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, source)
+		})
+	}
+}
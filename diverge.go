@@ -0,0 +1,204 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// OnDiverge is what a sync does when the chain in an item was edited by hand
+// since it was last synced.
+type OnDiverge string
+
+const (
+	DivergeStop      OnDiverge = "stop"
+	DivergeMerge     OnDiverge = "merge"
+	DivergeOverwrite OnDiverge = "overwrite"
+)
+
+func (o OnDiverge) String() string {
+	return string(o)
+}
+
+// Set implements flag.Value.
+func (o *OnDiverge) Set(value string) error {
+	switch onDiverge := OnDiverge(value); onDiverge {
+	case DivergeStop, DivergeMerge, DivergeOverwrite:
+		*o = onDiverge
+		return nil
+	}
+	return fmt.Errorf("unknown diverge action %q", value)
+}
+
+var ErrDiverged = errors.New("chain was edited in items since it was last synced")
+
+// divergence is an item whose chain was edited by hand since it was last
+// synced, and the items that were added and removed by the edit.
+type divergence struct {
+	Item    ChainIssue
+	Items   []ChainItem
+	Added   []ChainItem
+	Removed []ChainItem
+	// NoBase is set when the chain the item was last synced with is not in any
+	// body, so the edit cannot be told apart from the chain.
+	NoBase bool
+}
+
+// conflicts reports whether the edit cannot be merged: the chain it was synced
+// with is not known, or the items were only reordered or nested differently.
+func (d divergence) conflicts() bool {
+	return d.NoBase || len(d.Added)+len(d.Removed) == 0
+}
+
+// members returns the source and the issues of the items, which are the bodies
+// the chain is written to.
+func members(chain Chain) []ChainIssue {
+	members := []ChainIssue{chain.Source}
+	for _, item := range chain.Items {
+		if item.Number != 0 && !item.IsSame(chain.Source) {
+			members = append(members, item.ChainIssue)
+		}
+	}
+	return members
+}
+
+// findDivergences returns the items whose chain was edited since it was last
+// synced, unless the edit is already in the chain. The edits are found by
+// comparing to a body that still has the chain as it was last synced.
+func findDivergences(client *GhClient, chain Chain, fetched prefetched) []divergence {
+	indicator := chain.renderIndicator()
+	previous := map[ChainIssue]*Chain{}
+	synced := map[string]*Chain{}
+	for _, member := range members(chain) {
+		issue, err := fetched.get(client, member)
+		if err != nil {
+			// the sync reports the items that cannot be retrieved
+			slog.Debug("error retrieving item", "number", member.Number, "error", err)
+			continue
+		}
+		parsed, err := ParseGenerated(member, issue.Body, indicator)
+		if err != nil {
+			continue
+		}
+		previous[member.Key()] = parsed
+		if parsed.SyncedHash != "" && parsed.Hash() == parsed.SyncedHash {
+			synced[parsed.SyncedHash] = parsed
+		}
+	}
+
+	var divergences []divergence
+	for _, member := range members(chain)[1:] {
+		// edits to the source are how the chain is changed
+		parsed, ok := previous[member.Key()]
+		if !ok || parsed.SyncedHash == "" || parsed.Hash() == parsed.SyncedHash || parsed.Hash() == chain.Hash() {
+			continue
+		}
+
+		d := divergence{Item: member, Items: parsed.Items}
+		base, ok := synced[parsed.SyncedHash]
+		if !ok {
+			d.NoBase = true
+			divergences = append(divergences, d)
+			continue
+		}
+		for _, item := range parsed.Items {
+			if item.Number != 0 && base.Index(item.ChainIssue) < 0 {
+				d.Added = append(d.Added, item)
+			}
+		}
+		for _, item := range base.Items {
+			if item.Number != 0 && parsed.Index(item.ChainIssue) < 0 {
+				d.Removed = append(d.Removed, item)
+			}
+		}
+		divergences = append(divergences, d)
+	}
+	return divergences
+}
+
+// mergeDivergences removes the items the edits removed from the chain, and
+// inserts the items they added after the item before them in the edited chain.
+func mergeDivergences(chain Chain, divergences []divergence) Chain {
+	for _, d := range divergences {
+		for _, item := range d.Removed {
+			if i := chain.Index(item.ChainIssue); i >= 0 {
+				chain = chain.RemoveItem(i)
+			}
+		}
+		for _, item := range d.Added {
+			if chain.Index(item.ChainIssue) >= 0 {
+				continue
+			}
+			index, depth := mergePosition(chain, d.Items, item)
+			chain = chain.InsertItem(index, depth, item.ChainIssue)
+		}
+	}
+	return chain
+}
+
+// mergePosition returns the index and depth to insert the item at: after the
+// closest item before it in items at the same depth, or under its parent if it
+// is the first of its siblings. Items that are not in the chain are skipped, and
+// it is inserted first if there is neither.
+func mergePosition(chain Chain, items []ChainItem, item ChainItem) (int, int) {
+	k := slices.IndexFunc(items, func(other ChainItem) bool {
+		return other.Number != 0 && other.IsSame(item.ChainIssue)
+	})
+	for j := k - 1; j >= 0; j-- {
+		if items[j].Depth > item.Depth {
+			// nested under an item before it
+			continue
+		}
+		p := chain.Index(items[j].ChainIssue)
+		if items[j].Number == 0 || p < 0 {
+			continue
+		}
+		if items[j].Depth < item.Depth {
+			return p + 1, chain.Items[p].Depth + 1
+		}
+		return chain.subtreeEnd(p), chain.Items[p].Depth
+	}
+	return 0, 0
+}
+
+// String describes the edit.
+func (d divergence) String() string {
+	switch {
+	case d.NoBase:
+		return "the chain it was last synced with was not found"
+	case d.conflicts():
+		return "items were reordered"
+	}
+	var changes []string
+	for _, item := range d.Added {
+		changes = append(changes, "added "+item.Ref())
+	}
+	for _, item := range d.Removed {
+		changes = append(changes, "removed "+item.Ref())
+	}
+	return strings.Join(changes, ", ")
+}
+
+// renderDivergences prints the edit in each item that was not merged, and
+// returns ErrDiverged.
+func renderDivergences(w io.Writer, divergences []divergence, onDiverge OnDiverge) error {
+	for _, d := range divergences {
+		if onDiverge != DivergeMerge || d.conflicts() {
+			_, _ = fmt.Fprintln(w, red("✗"), bold(d.Item.Ref()), d)
+		}
+	}
+	if onDiverge == DivergeMerge {
+		return fmt.Errorf("%w: edit the source to match, or pass --on-diverge overwrite to discard the edits", ErrDiverged)
+	}
+	return fmt.Errorf("%w: pass --on-diverge merge to keep the edits, or --on-diverge overwrite to discard them", ErrDiverged)
+}
+
+// renderMerged prints the edit in each item that was merged into the chain.
+func renderMerged(w io.Writer, divergences []divergence) {
+	for _, d := range divergences {
+		_, _ = fmt.Fprintln(w, green("✓"), bold(d.Item.Ref()), "merged:", d)
+	}
+}
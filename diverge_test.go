@@ -0,0 +1,119 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain_HashRoundTrip(t *testing.T) {
+	other := ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "RoryQ", Name: "other"}, Number: 7}
+	chain, err := Parse(TestIssue, `<!--chainlink-->
+1. #1
+2. Rollout
+   - [x] #2
+   - [ ] #3`)
+	assert.NoError(t, err)
+	chain.Items[2].Title = "Add parser"
+	chain.Items[2].IssueState = StateMerged
+	withOther := chain.InsertItem(4, 0, other).WithBadges(EmojiBadges)
+
+	for _, current := range []ChainIssue{TestIssue, other} {
+		rendered := withOther.ResetCurrent(current).RenderMarkdown()
+		parsed, err := Parse(current, rendered)
+		assert.NoError(t, err)
+		assert.Equal(t, withOther.Hash(), parsed.SyncedHash, rendered)
+		assert.Equal(t, withOther.Hash(), parsed.Hash(), rendered)
+	}
+}
+
+func TestFindDivergences(t *testing.T) {
+	synced := NewChain([]ChainIssue{repoIssue(1), repoIssue(2), repoIssue(3)})
+	body := func(member int, replacer ...string) string {
+		rendered := synced.ResetCurrent(repoIssue(member)).RenderMarkdown()
+		return "Description\n\n" + strings.NewReplacer(replacer...).Replace(rendered)
+	}
+	chain := synced.InsertItem(3, 0, repoIssue(5))
+
+	tests := map[string]struct {
+		bodies map[int]string
+		want   map[int]string
+	}{
+		"Unchanged": {
+			bodies: map[int]string{1: body(1), 2: body(2), 3: body(3)},
+		},
+		"AddedAndRemoved": {
+			bodies: map[int]string{1: body(1), 2: body(2), 3: body(3, "\n2. #2 ", "", "3. #3 &larr; you are here", "3. #3 &larr; you are here \n4. #4")},
+			want:   map[int]string{3: "added RoryQ/gh-chainlink#4, removed RoryQ/gh-chainlink#2"},
+		},
+		"EditedSourceIsNotDivergence": {
+			bodies: map[int]string{1: body(1, "\n2. #2 ", ""), 2: body(2), 3: body(3)},
+		},
+		"EditIsAlreadyInChain": {
+			bodies: map[int]string{1: body(1), 2: body(2), 3: body(3, "3. #3 &larr; you are here", "3. #3 &larr; you are here \n4. #5")},
+		},
+		"Reordered": {
+			bodies: map[int]string{1: body(1), 2: body(2), 3: body(3, "2. #2 \n3. #3 &larr; you are here", "2. #3 &larr; you are here \n3. #2")},
+			want:   map[int]string{3: "items were reordered"},
+		},
+		"NoBase": {
+			bodies: map[int]string{1: body(1, "\n2. #2 ", ""), 2: body(2, "1. #1 ", ""), 3: body(3, "1. #1 ", "1. #4 ")},
+			want: map[int]string{
+				2: "the chain it was last synced with was not found",
+				3: "the chain it was last synced with was not found",
+			},
+		},
+		"NotSyncedBefore": {
+			bodies: map[int]string{1: "<!--chainlink-->\n1. #1\n2. #2", 2: "<!--chainlink-->\n1. #2", 3: ""},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			fetched := prefetched{repoIssue(5): {}}
+			for number, body := range tt.bodies {
+				fetched[repoIssue(number)] = IssueResponse{Body: body}
+			}
+
+			got := map[int]string{}
+			for _, d := range findDivergences(nil, chain, fetched) {
+				got[d.Item.Number] = d.String()
+			}
+			assert.Equal(t, iif(tt.want == nil, map[int]string{}, tt.want), got)
+		})
+	}
+}
+
+func TestMergeDivergences(t *testing.T) {
+	chain, err := Parse(TestIssue, `<!--chainlink-->
+1. #1
+2. #2
+   - #3
+3. #4`)
+	assert.NoError(t, err)
+	edited, err := Parse(repoIssue(2), `<!--chainlink-->
+1. #6
+2. #1
+3. #2
+   - #3
+   - #7
+4. #8`)
+	assert.NoError(t, err)
+
+	merged := mergeDivergences(*chain, []divergence{{
+		Item:    repoIssue(2),
+		Items:   edited.Items,
+		Added:   []ChainItem{edited.Items[0], edited.Items[4], edited.Items[5]},
+		Removed: []ChainItem{chain.Items[3]},
+	}})
+
+	expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=4860edb2 --> 
+1. #6 
+2. #1 &larr; you are here 
+3. #2 
+   - #3 
+   - #7 
+4. #8`
+	assert.Equal(t, expected, merged.ResetCurrent(TestIssue).RenderMarkdown())
+}
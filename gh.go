@@ -3,10 +3,8 @@ package main
 import (
 	"bytes"
 	"encoding/json"
-	"errors"
 	"fmt"
-	"log/slog"
-	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/cli/go-gh/v2/pkg/api"
@@ -16,8 +14,15 @@ import (
 
 type GhClient struct {
 	clientLookup  map[string]*api.RESTClient
+	graphqlLookup map[string]*api.GraphQLClient
 	currentRepo   repository.Repository
 	currentBranch string
+
+	// OnWait is called before waiting to retry a rate limited or failed request.
+	OnWait func(wait time.Duration, err error)
+	// MaxWait is the longest wait before retrying a request, or defaultMaxWait if
+	// it is not set.
+	MaxWait time.Duration
 }
 
 func (c *GhClient) InGitRepo() bool {
@@ -36,15 +41,53 @@ func NewGhClient() (*GhClient, error) {
 		host: apiClient,
 	}
 
-	return &GhClient{clientLookup: apiClientHostLookup, currentRepo: current}, nil
+	return &GhClient{
+		clientLookup:  apiClientHostLookup,
+		graphqlLookup: map[string]*api.GraphQLClient{},
+		currentRepo:   current,
+	}, nil
 }
 
 type IssueResponse struct {
-	Title  string
-	Body   string
-	Number int
-	State  string
-	Url    string
+	Title       string
+	Body        string
+	Number      int
+	State       string
+	Url         string
+	Draft       bool
+	UpdatedAt   time.Time       `json:"updated_at"`
+	MergedAt    *time.Time      `json:"merged_at"`
+	PullRequest *pullRequestRef `json:"pull_request"`
+
+	// ReviewDecision, Checks, Head and Base are only set for pull requests
+	// fetched with GraphQL.
+	ReviewDecision string `json:"-"`
+	Checks         string `json:"-"`
+	Head           string `json:"-"`
+	Base           string `json:"-"`
+}
+
+// pullRequestRef is only present on issue responses for pull requests.
+type pullRequestRef struct {
+	MergedAt *time.Time `json:"merged_at"`
+}
+
+// IsPull reports whether the issue response is for a pull request.
+func (r IssueResponse) IsPull() bool {
+	return r.PullRequest != nil
+}
+
+// IssueState returns the state of the issue, or of the pull request if it is one.
+func (r IssueResponse) IssueState() IssueState {
+	switch {
+	case r.MergedAt != nil, r.PullRequest != nil && r.PullRequest.MergedAt != nil:
+		return StateMerged
+	case r.State == "closed":
+		return StateClosed
+	case r.Draft:
+		return StateDraft
+	}
+	return StateOpen
 }
 
 func (c *GhClient) GetIssue(issue ChainIssue) (IssueResponse, error) {
@@ -53,41 +96,102 @@ func (c *GhClient) GetIssue(issue ChainIssue) (IssueResponse, error) {
 	if err != nil {
 		return IssueResponse{}, err
 	}
-	err = client.Get(issue.Path(), &response)
+	err = c.withRetry(func() error {
+		return client.Get(issue.Path(), &response)
+	})
 	if err != nil {
 		return IssueResponse{}, err
 	}
 	return response, nil
 }
 
-func (c *GhClient) IsPull(issue ChainIssue) bool {
-	apiPath := fmt.Sprintf("repos/%s/%s/issues/%d", issue.Repo.Owner, issue.Repo.Name, issue.Number)
-	response := IssueResponse{}
+type PullResponse struct {
+	Title    string
+	Body     string
+	Number   int
+	State    string
+	Draft    bool
+	MergedAt *time.Time `json:"merged_at"`
+	HtmlUrl  string     `json:"html_url"`
+	Head     BranchRef
+	Base     BranchRef
+}
+
+type BranchRef struct {
+	Ref   string
+	Label string
+}
+
+func (c *GhClient) GetPull(issue ChainIssue) (PullResponse, error) {
+	issue.IsPullRequest = true
+	response := PullResponse{}
 	client, err := c.getClient(issue.Repo.Host)
 	if err != nil {
-		slog.Error("Error getting client", "error", err)
-		return false
+		return PullResponse{}, err
 	}
-	err = client.Get(apiPath, &response)
-	he := &api.HTTPError{}
-	if errors.As(err, &he) {
-		return he.StatusCode != http.StatusNotFound
+	err = c.withRetry(func() error {
+		return client.Get(issue.Path(), &response)
+	})
+	if err != nil {
+		return PullResponse{}, err
 	}
+	return response, nil
+}
+
+// ListOpenPulls returns the open pull requests in the repo filtered by head and
+// base branch. Empty filters are ignored.
+func (c *GhClient) ListOpenPulls(repo repository.Repository, head, base string) ([]PullResponse, error) {
+	query := url.Values{"state": {"open"}}
+	if head != "" {
+		query.Set("head", repo.Owner+":"+head)
+	}
+	if base != "" {
+		query.Set("base", base)
+	}
+
+	var response []PullResponse
+	client, err := c.getClient(repo.Host)
+	if err != nil {
+		return nil, err
+	}
+	err = c.withRetry(func() error {
+		return client.Get(fmt.Sprint("repos/", repo.Owner, "/", repo.Name, "/pulls?", query.Encode()), &response)
+	})
 	if err != nil {
-		slog.Error("Error getting issue", "error", err)
-		return false
+		return nil, err
 	}
-	return true
+	return response, nil
 }
 
 func (c *GhClient) UpdateIssueBody(issue ChainIssue, body string) error {
 	response := map[string]any{}
-	request, err := c.encodeJson(map[string]any{"body": body})
+	client, err := c.getClient(issue.Repo.Host)
 	if err != nil {
 		return err
 	}
-	err = c.clientLookup[issue.Repo.Host].Patch(issue.Path(), request, &response)
-	return err
+	return c.withRetry(func() error {
+		request, err := c.encodeJson(map[string]any{"body": body})
+		if err != nil {
+			return err
+		}
+		return client.Patch(issue.Path(), request, &response)
+	})
+}
+
+func (c *GhClient) UpdatePullBase(issue ChainIssue, base string) error {
+	issue.IsPullRequest = true
+	response := map[string]any{}
+	client, err := c.getClient(issue.Repo.Host)
+	if err != nil {
+		return err
+	}
+	return c.withRetry(func() error {
+		request, err := c.encodeJson(map[string]any{"base": base})
+		if err != nil {
+			return err
+		}
+		return client.Patch(issue.Path(), request, &response)
+	})
 }
 
 func (c *GhClient) encodeJson(request map[string]any) (*bytes.Buffer, error) {
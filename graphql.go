@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+const issueFields = `
+	__typename
+	... on Issue { title body state url updatedAt }
+	... on PullRequest {
+		title body state url updatedAt isDraft mergedAt reviewDecision headRefName baseRefName
+		commits(last: 1) { nodes { commit { statusCheckRollup { state } } } }
+	}`
+
+type graphqlIssue struct {
+	Typename  string `json:"__typename"`
+	Title     string
+	Body      string
+	State     string
+	Url       string
+	UpdatedAt time.Time
+	IsDraft   bool
+	MergedAt  *time.Time
+
+	ReviewDecision string
+	HeadRefName    string
+	BaseRefName    string
+	Commits        struct {
+		Nodes []struct {
+			Commit struct {
+				StatusCheckRollup *struct {
+					State string
+				}
+			}
+		}
+	}
+}
+
+func (g graphqlIssue) toIssueResponse(number int) IssueResponse {
+	response := IssueResponse{
+		Title:     g.Title,
+		Body:      g.Body,
+		Number:    number,
+		State:     strings.ToLower(g.State),
+		Url:       g.Url,
+		Draft:     g.IsDraft,
+		UpdatedAt: g.UpdatedAt,
+	}
+	if g.Typename == "PullRequest" {
+		response.PullRequest = &pullRequestRef{MergedAt: g.MergedAt}
+		response.ReviewDecision = strings.ToLower(g.ReviewDecision)
+		response.Head, response.Base = g.HeadRefName, g.BaseRefName
+		if nodes := g.Commits.Nodes; len(nodes) > 0 && nodes[0].Commit.StatusCheckRollup != nil {
+			response.Checks = strings.ToLower(nodes[0].Commit.StatusCheckRollup.State)
+		}
+	}
+	return response
+}
+
+// FetchIssues retrieves every issue with a single GraphQL query per host. Issues
+// that could not be retrieved are missing from the result so the caller can
+// fall back to the REST API.
+func (c *GhClient) FetchIssues(issues []ChainIssue) map[ChainIssue]IssueResponse {
+	byHost := map[string][]ChainIssue{}
+	for _, issue := range issues {
+		byHost[issue.Repo.Host] = append(byHost[issue.Repo.Host], issue.Key())
+	}
+
+	fetched := map[ChainIssue]IssueResponse{}
+	for host, hostIssues := range byHost {
+		if err := c.fetchIssuesForHost(host, hostIssues, fetched); err != nil {
+			slog.Debug("error fetching issues with graphql", "host", host, "error", err)
+		}
+	}
+	return fetched
+}
+
+func (c *GhClient) fetchIssuesForHost(host string, issues []ChainIssue, fetched map[ChainIssue]IssueResponse) error {
+	client, err := c.getGraphQLClient(host)
+	if err != nil {
+		return err
+	}
+
+	query, variables := issuesQuery(issues)
+	response := map[string]*struct {
+		IssueOrPullRequest *graphqlIssue
+	}{}
+
+	// partial responses are still populated when some issues are not found
+	err = c.withRetry(func() error {
+		return client.Do(query, variables, &response)
+	})
+	for i, issue := range issues {
+		alias := response[fmt.Sprint("i", i)]
+		if alias == nil || alias.IssueOrPullRequest == nil {
+			continue
+		}
+		fetched[issue] = alias.IssueOrPullRequest.toIssueResponse(issue.Number)
+	}
+	return err
+}
+
+// issuesQuery builds a query with an aliased issueOrPullRequest lookup for each issue.
+func issuesQuery(issues []ChainIssue) (string, map[string]any) {
+	variables := map[string]any{}
+	var params, fields []string
+	for i, issue := range issues {
+		params = append(params, fmt.Sprintf("$o%d: String!, $r%d: String!, $n%d: Int!", i, i, i))
+		fields = append(fields, fmt.Sprintf(
+			"i%d: repository(owner: $o%d, name: $r%d) { issueOrPullRequest(number: $n%d) { %s } }",
+			i, i, i, i, issueFields))
+		variables[fmt.Sprint("o", i)] = issue.Repo.Owner
+		variables[fmt.Sprint("r", i)] = issue.Repo.Name
+		variables[fmt.Sprint("n", i)] = issue.Number
+	}
+
+	query := fmt.Sprintf("query(%s) {\n%s\n}", strings.Join(params, ", "), strings.Join(fields, "\n"))
+	return query, variables
+}
+
+func (c *GhClient) getGraphQLClient(host string) (*api.GraphQLClient, error) {
+	if client, ok := c.graphqlLookup[host]; ok {
+		return client, nil
+	}
+
+	client, err := api.NewGraphQLClient(api.ClientOptions{
+		Host:    host,
+		Timeout: 30 * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.graphqlLookup[host] = client
+	return client, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssuesQuery(t *testing.T) {
+	other := ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "owner", Name: "repo"}, Number: 7}
+
+	query, variables := issuesQuery([]ChainIssue{TestIssue, other})
+
+	assert.Contains(t, query, "query($o0: String!, $r0: String!, $n0: Int!, $o1: String!, $r1: String!, $n1: Int!) {")
+	assert.Contains(t, query, "i0: repository(owner: $o0, name: $r0) { issueOrPullRequest(number: $n0) {")
+	assert.Contains(t, query, "i1: repository(owner: $o1, name: $r1) { issueOrPullRequest(number: $n1) {")
+	assert.Equal(t, map[string]any{
+		"o0": "RoryQ", "r0": "gh-chainlink", "n0": 1,
+		"o1": "owner", "r1": "repo", "n1": 7,
+	}, variables)
+}
+
+func TestGraphqlIssue_toIssueResponse(t *testing.T) {
+	tests := map[string]struct {
+		issue     graphqlIssue
+		wantPull  bool
+		wantState IssueState
+	}{
+		"OpenIssue": {
+			issue:     graphqlIssue{Typename: "Issue", State: "OPEN"},
+			wantState: StateOpen,
+		},
+		"ClosedIssue": {
+			issue:     graphqlIssue{Typename: "Issue", State: "CLOSED"},
+			wantState: StateClosed,
+		},
+		"DraftPull": {
+			issue:     graphqlIssue{Typename: "PullRequest", State: "OPEN", IsDraft: true},
+			wantPull:  true,
+			wantState: StateDraft,
+		},
+		"MergedPull": {
+			issue:     graphqlIssue{Typename: "PullRequest", State: "MERGED", MergedAt: &time.Time{}},
+			wantPull:  true,
+			wantState: StateMerged,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			response := tt.issue.toIssueResponse(1)
+			assert.Equal(t, tt.wantPull, response.IsPull())
+			assert.Equal(t, tt.wantState, response.IssueState())
+		})
+	}
+}
+
+func TestGraphqlIssue_toIssueResponsePullDetails(t *testing.T) {
+	issue := graphqlIssue{Typename: "PullRequest", State: "OPEN", ReviewDecision: "CHANGES_REQUESTED", HeadRefName: "feature", BaseRefName: "main"}
+	issue.Commits.Nodes = append(issue.Commits.Nodes, struct {
+		Commit struct{ StatusCheckRollup *struct{ State string } }
+	}{})
+	issue.Commits.Nodes[0].Commit.StatusCheckRollup = &struct{ State string }{State: "FAILURE"}
+
+	response := issue.toIssueResponse(1)
+	assert.Equal(t, "changes_requested", response.ReviewDecision)
+	assert.Equal(t, "failure", response.Checks)
+	assert.Equal(t, "feature", response.Head)
+	assert.Equal(t, "main", response.Base)
+}
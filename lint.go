@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+var ErrLintProblems = errors.New("chain has problems")
+
+// lintProblem is a problem with a chain, found on a line of the body.
+type lintProblem struct {
+	Line    int
+	Message string
+}
+
+// lintItem is a chain item and the line it was parsed from.
+type lintItem struct {
+	ChainItem
+	Line int
+}
+
+func lintCommand(client *GhClient, args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	name := fs.String("chain", "", "Only lint the chain with this name when the body has more than one.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	targetIssue := getTargetIssue(fs.Args())
+	if targetIssue.Number == 0 {
+		return ErrNoIssueRef
+	}
+	issue, err := client.GetIssue(targetIssue)
+	if err != nil {
+		return err
+	}
+
+	problems, items := lintBody(targetIssue, issue.Body, *name)
+	missing, err := lintMissing(client, items)
+	if err != nil {
+		return err
+	}
+	problems = append(problems, missing...)
+
+	return renderProblems(os.Stdout, targetIssue, problems)
+}
+
+// lintBody returns the problems with the chains in the body that can be found
+// without looking up the items, and the items that refer to an issue.
+func lintBody(current ChainIssue, body, name string) ([]lintProblem, []lintItem) {
+	doc := parseDocument(body)
+
+	var problems []lintProblem
+	var refs []lintItem
+	found := false
+	for _, block := range doc.chains() {
+		indicator := parseIndicator(block.Indicator.Raw)
+		if name != "" && !strings.EqualFold(indicator.Name, name) {
+			continue
+		}
+		found = true
+		problem := func(line int, format string, a ...any) {
+			problems = append(problems, lintProblem{Line: line, Message: fmt.Sprintf(format, a...)})
+		}
+
+		if block.End < 0 {
+			problem(block.Indicator.LineNumber, "indicator has no list after it")
+			continue
+		}
+
+		items, lines := doc.items(current, block)
+		if len(items) == 0 {
+			problem(block.Indicator.LineNumber, "chain has no items")
+			continue
+		}
+
+		seen := map[ChainIssue]int{}
+		for i, item := range items {
+			if item.Number == 0 {
+				// group headings in nested chains have no ref
+				if i+1 < len(items) && items[i+1].Depth > item.Depth {
+					continue
+				}
+				problem(lines[i], "item %q does not refer to an issue or pull request", item.Message)
+				continue
+			}
+
+			first, ok := seen[item.Key()]
+			switch {
+			case !ok:
+				seen[item.Key()] = lines[i]
+				refs = append(refs, lintItem{ChainItem: item, Line: lines[i]})
+			case !block.HasEnd:
+				// templates may link to an item more than once e.g. in previous and next links
+				problem(lines[i], "item %s is already in the chain on line %d", item.Ref(), first+1)
+			}
+		}
+
+		if _, ok := seen[current.Key()]; !ok {
+			problem(block.Indicator.LineNumber, "%s is not in its own chain", current.Ref())
+		}
+	}
+
+	if !found {
+		problems = append(problems, lintProblem{Line: -1, Message: iif(name == "", ErrNotFound.Error(), fmt.Sprintf("%s with name %s", ErrNotFound, name))})
+	}
+	return problems, refs
+}
+
+// lintMissing returns a problem for each item that refers to an issue that does not exist.
+func lintMissing(client *GhClient, items []lintItem) ([]lintProblem, error) {
+	issues := make([]ChainIssue, len(items))
+	for i, item := range items {
+		issues[i] = item.ChainIssue
+	}
+	fetched := prefetched(client.FetchIssues(issues))
+
+	var problems []lintProblem
+	for _, item := range items {
+		_, err := fetched.get(client, item.ChainIssue)
+		he := &api.HTTPError{}
+		if errors.As(err, &he) && he.StatusCode == http.StatusNotFound {
+			problems = append(problems, lintProblem{Line: item.Line, Message: fmt.Sprintf("item %s does not exist", item.Ref())})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving item %d: %w", item.Number, err)
+		}
+	}
+	return problems, nil
+}
+
+// renderProblems prints each problem with its line number, and returns
+// ErrLintProblems if there are any.
+func renderProblems(w io.Writer, issue ChainIssue, problems []lintProblem) error {
+	if len(problems) == 0 {
+		_, _ = fmt.Fprintln(w, green("✓"), issue.Ref(), "has no problems")
+		return nil
+	}
+	slices.SortStableFunc(problems, func(a, b lintProblem) int { return a.Line - b.Line })
+	for _, p := range problems {
+		location := issue.Ref()
+		if p.Line >= 0 {
+			location += fmt.Sprint(":", p.Line+1)
+		}
+		_, _ = fmt.Fprintln(w, red("✗"), bold(location), p.Message)
+	}
+	return fmt.Errorf("%w: %d found", ErrLintProblems, len(problems))
+}
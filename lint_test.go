@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLintBody(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		name     string
+		want     []lintProblem
+		wantRefs []int
+	}{
+		"Valid": {
+			body:     "<!-- chainlink -->\n1. #1\n2. #2",
+			wantRefs: []int{1, 2},
+		},
+		"IndicatorWithoutList": {
+			body: "Text\n\n<!-- chainlink -->\n\nMore text",
+			want: []lintProblem{{Line: 2, Message: "indicator has no list after it"}},
+		},
+		"ItemWithoutRef": {
+			body:     "<!-- chainlink -->\n1. #1\n2. the next one",
+			want:     []lintProblem{{Line: 2, Message: `item "the next one" does not refer to an issue or pull request`}},
+			wantRefs: []int{1},
+		},
+		"GroupHeadingWithoutRef": {
+			body:     "<!-- chainlink -->\n1. #1\n2. Rollout\n   - #2",
+			wantRefs: []int{1, 2},
+		},
+		"DuplicateItem": {
+			body:     "<!-- chainlink -->\n1. #1\n2. #2\n3. #2",
+			want:     []lintProblem{{Line: 3, Message: "item RoryQ/gh-chainlink#2 is already in the chain on line 3"}},
+			wantRefs: []int{1, 2},
+		},
+		"SourceNotInChain": {
+			body:     "<!-- chainlink -->\n1. #2\n2. #3",
+			want:     []lintProblem{{Line: 0, Message: "RoryQ/gh-chainlink#1 is not in its own chain"}},
+			wantRefs: []int{2, 3},
+		},
+		"NoChain": {
+			body: "Just a description",
+			want: []lintProblem{{Line: -1, Message: "no chainlink list found"}},
+		},
+		"OtherChainsAreIgnored": {
+			body:     "<!-- chainlink name=backend -->\n1. #1\n\n<!-- chainlink name=rollout -->\nnot a list",
+			name:     "backend",
+			wantRefs: []int{1},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			problems, refs := lintBody(TestIssue, tt.body, tt.name)
+			assert.Equal(t, tt.want, problems)
+
+			var numbers []int
+			for _, ref := range refs {
+				numbers = append(numbers, ref.Number)
+			}
+			assert.Equal(t, tt.wantRefs, numbers)
+		})
+	}
+}
+
+func TestRenderProblems(t *testing.T) {
+	color.NoColor = true
+	buf := new(bytes.Buffer)
+	err := renderProblems(buf, TestIssue, []lintProblem{
+		{Line: 4, Message: "item RoryQ/gh-chainlink#9 does not exist"},
+		{Line: 1, Message: "indicator has no list after it"},
+	})
+	assert.ErrorIs(t, err, ErrLintProblems)
+	assert.Equal(t, "✗ RoryQ/gh-chainlink#1:2 indicator has no list after it\n✗ RoryQ/gh-chainlink#1:5 item RoryQ/gh-chainlink#9 does not exist\n", buf.String())
+
+	buf.Reset()
+	assert.NoError(t, renderProblems(buf, TestIssue, nil))
+}
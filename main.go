@@ -2,16 +2,23 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/cli/go-gh/v2"
+	"github.com/cli/go-gh/v2/pkg/auth"
 	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/cli/go-gh/v2/pkg/term"
 	"github.com/fatih/color"
 	"github.com/sourcegraph/conc/pool"
 )
@@ -25,19 +32,66 @@ var (
 	blue    = color.New(color.FgHiBlue).SprintFunc()
 )
 
+// stringFunc adapts a color function for use with tableprinter.WithColor.
+func stringFunc(f func(a ...any) string) func(string) string {
+	return func(s string) string { return f(s) }
+}
+
+// commands are the subcommands that can be given before the issue ref.
+var commands = map[string]func(client *GhClient, args []string) error{
+	"add":      addCommand,
+	"create":   createCommand,
+	"lint":     lintCommand,
+	"move":     moveCommand,
+	"remove":   removeCommand,
+	"retarget": retargetCommand,
+	"status":   statusCommand,
+	"unlink":   unlinkCommand,
+}
+
+var ErrNoIssueRef = errors.New("no issue ref given and no pull request found for the current branch")
+
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(color.Output, "%s\n\n", "Chainlink - link chained pull requests and issues.")
 		fmt.Fprintf(color.Output, "%s\n", bold("USAGE"))
-		fmt.Fprintf(color.Output, "  %s\n\n", "gh chainlink <issue ref>")
+		fmt.Fprintf(color.Output, "  %s\n", "gh chainlink [flags] <issue ref>")
+		fmt.Fprintf(color.Output, "  %s\n\n", "gh chainlink <command> [flags]")
+		fmt.Fprintf(color.Output, "%s", bold("COMMANDS"))
+		fmt.Fprintf(color.Output, "%s\n", `
+  add:      Add an item to a chain and sync every item.
+  create:   Create a chain from the stack of local branches ending at the current branch.
+  lint:     Check the chain in the body of the issue ref for problems, exiting non-zero if there are any.
+  move:     Move an item to another position in a chain and sync every item.
+  remove:   Remove an item from a chain, sync the rest and remove the chain from the item.
+  retarget: Set the base branch of each pull request to the head branch of the one before it in the chain.
+  status:   Show the live state, reviews, checks and branches of each item and whether its chain is in sync, without updating anything.
+  unlink:   Remove the chain from the body of every item, and optionally the source.
+  `)
 		fmt.Fprintf(color.Output, "%s", bold("ISSUE REF"))
 		fmt.Fprintf(color.Output, "%s\n", `
   autodetect: Leave empty to use the pull request for the current branch.
   number:   Enter the issue or pull request number for the current repo e.g. 123.
+  shorthand: Enter owner/repo#123, or repo#123 for a repo with the current owner. The repo needs a lowercase letter unless the owner is given.
   url: Enter the issue or pull request url e.g. https://github.com/RoryQ/gh-chainlink/issues/1
   `)
 		flag.PrintDefaults()
 	}
+
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			client := must(NewGhClient())
+			if err := command(client, os.Args[2:]); err != nil {
+				slog.Error("Error running "+os.Args[1], "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	discover := flag.Bool("discover", false, "Build the chain by following pull request base branches instead of parsing the body.")
+	name := flag.String("chain", "", "Only sync the chain with this name when the body has more than one.")
+	opts := addSyncFlags(flag.CommandLine)
 	flag.Parse()
 	args := flag.Args()
 
@@ -52,70 +106,327 @@ func main() {
 		os.Exit(0)
 	}
 
-	// get chain from ref issue
-	issue := must(client.GetIssue(targetIssue))
-	chain := must(Parse(targetIssue, issue.Body))
+	if err := configure(client, flag.CommandLine, opts, targetIssue.Repo); err != nil {
+		slog.Error("Error reading config", "error", err)
+		os.Exit(1)
+	}
 
-	_, err := tea.NewProgram(model{
-		gh:        client,
-		sub:       make(chan responseMsg),
-		responses: make(map[int]responseMsg),
-		chain:     *chain,
-	}).Run()
+	var chains []*Chain
+	switch {
+	case *discover:
+		chain := must(DiscoverChain(client, targetIssue))
+		issue := must(client.GetIssue(targetIssue))
+		if source, ok := generatedSource(targetIssue, issue.Body); ok {
+			chain.Source = source
+		}
+		chains = append(chains, chain)
+	case *name != "":
+		issue := must(client.GetIssue(targetIssue))
+		chains = append(chains, must(ParseNamed(targetIssue, issue.Body, *name)))
+	default:
+		// get every chain from ref issue
+		issue := must(client.GetIssue(targetIssue))
+		chains = must(ParseAll(targetIssue, issue.Body))
+	}
 
+	// chains are synced one at a time so that shared items are not updated concurrently
+	for _, chain := range chains {
+		if err := runSync(client, *chain, *opts); err != nil {
+			slog.Error("Error running program", "error", err)
+			os.Exit(1)
+		}
+	}
+}
+
+type syncOptions struct {
+	// DryRun computes the updated bodies without writing them.
+	DryRun bool
+	// JSON prints machine-readable records instead of the interactive view.
+	JSON bool
+	// Badges renders the live state of each item in this style.
+	Badges BadgeStyle
+	// SyncCheckboxes checks checklist items that are merged or closed.
+	SyncCheckboxes bool
+	// Titles renders the live title of each item after its ref.
+	Titles bool
+	// TemplatePath is a Go template file to render the items with instead of a list.
+	TemplatePath string
+	// RepoTemplate is the template named in the repo config, which is used
+	// instead of TemplatePath as it was read from the repo.
+	RepoTemplate *template.Template
+	// Indicator replaces the default current indicator.
+	Indicator string
+	// ListStyle renders every item with this list point when set.
+	ListStyle ListStyle
+	// Concurrency is the number of items fetched and updated at once.
+	Concurrency int
+	// Header is rendered above chains that do not have one.
+	Header string
+	// Insert is where the chain is added to bodies that do not have it.
+	Insert Insert
+	// Mermaid renders a mermaid graph of the chain under the list.
+	Mermaid bool
+	// UpdateSource writes the chain to the source when it is not one of the
+	// items, after the chain was edited.
+	UpdateSource bool
+	// OnDiverge is what to do when the chain in an item was edited since it was last synced.
+	OnDiverge OnDiverge
+	// MaxWait is the longest wait for a rate limit to reset before failing.
+	MaxWait time.Duration
+}
+
+func addSyncFlags(fs *flag.FlagSet) *syncOptions {
+	opts := addRenderFlags(fs)
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Print a diff of each body that would be updated without updating it.")
+	fs.BoolVar(&opts.JSON, "json", false, "Print one JSON record per item and a summary instead of the interactive view.")
+	opts.OnDiverge = DivergeStop
+	fs.Var(&opts.OnDiverge, "on-diverge", "What to do when the chain in an item was edited since it was last synced: `stop`, merge the edits into the chain or overwrite them.")
+	return opts
+}
+
+// addRenderFlags adds the flags that change how the chain is rendered.
+func addRenderFlags(fs *flag.FlagSet) *syncOptions {
+	opts := &syncOptions{Insert: InsertBottom}
+	fs.Var(&opts.Badges, "badges", "Show the state of each item as an `emoji` or `text` badge.")
+	fs.BoolVar(&opts.SyncCheckboxes, "sync-checkboxes", false, "Check checklist items that are merged or closed and uncheck the rest.")
+	fs.BoolVar(&opts.Titles, "titles", false, "Show the title of each item after its ref.")
+	fs.StringVar(&opts.TemplatePath, "template", "", "Go template `file` to render the chain with. Defaults to .github/chainlink.tmpl in the repo or chainlink.tmpl in the gh config dir.")
+	fs.StringVar(&opts.Indicator, "indicator", "", "Text to mark the current item with instead of \""+DefaultCurrentIndicator+"\".")
+	fs.Var(&opts.ListStyle, "list-style", "Render every item as `numbered`, `bulleted` or `checklist` instead of keeping the existing style.")
+	fs.IntVar(&opts.Concurrency, "concurrency", 5, "Number of items to fetch and update at once.")
+	fs.StringVar(&opts.Header, "header", "", "Markdown heading to render above chains without one e.g. \"## PR Chain\".")
+	fs.BoolVar(&opts.Mermaid, "mermaid", false, "Render a mermaid graph of the chain under the list.")
+	fs.Var(&opts.Insert, "insert", "Where to add the chain to bodies that do not have it, `top` or `bottom`.")
+	fs.DurationVar(&opts.MaxWait, "max-wait", defaultMaxWait, "Longest `duration` to wait for a rate limit to reset before failing.")
+	return opts
+}
+
+// runSync updates every item in the chain and reports the results.
+func runSync(client *GhClient, chain Chain, opts syncOptions) error {
+	chain, fetched, err := prepareChain(client, chain, opts)
 	if err != nil {
-		slog.Error("Error running program", "error", err)
-		os.Exit(1)
+		return err
+	}
+
+	// keep the edits made to the chain in the items since it was last synced
+	if opts.OnDiverge != DivergeOverwrite {
+		if divergences := findDivergences(client, chain, fetched); len(divergences) > 0 {
+			// keep the JSON output to records
+			w := iif(opts.JSON, os.Stderr, os.Stdout)
+			if opts.OnDiverge != DivergeMerge || slices.ContainsFunc(divergences, divergence.conflicts) {
+				return renderDivergences(w, divergences, opts.OnDiverge)
+			}
+			renderMerged(w, divergences)
+			opts.UpdateSource = true
+			if chain, fetched, err = prepareChain(client, mergeDivergences(chain, divergences), opts); err != nil {
+				return err
+			}
+		}
 	}
+
+	// find the items removed since the chain was last written, before it is written again
+	removed := removedItems(client, chain, fetched)
+
+	removedResults, removeErr := removeChains(os.Stdout, client, chain, removed, opts)
+
+	switch {
+	case opts.JSON:
+		err = runJSON(os.Stdout, client, chain, fetched, removedResults, opts)
+	case !term.FromEnv().IsTerminalOutput():
+		err = runPlain(os.Stdout, client, chain, fetched, opts)
+	default:
+		p := tea.NewProgram(model{
+			gh:        client,
+			sub:       make(chan responseMsg),
+			responses: make(map[int]responseMsg),
+			chain:     chain,
+			fetched:   fetched,
+			opts:      opts,
+		})
+		client.OnWait = func(wait time.Duration, err error) {
+			p.Send(waitingMsg{until: time.Now().Add(wait), err: err})
+		}
+		defer func() { client.OnWait = nil }()
+		_, err = p.Run()
+	}
+	return errors.Join(removeErr, err)
 }
 
-func updateIssue(client *GhClient, chain Chain, item ChainItem) (string, error) {
-	item.IsPullRequest = client.IsPull(item.ChainIssue)
-	// update the CurrentLocationIndicator to the current issue
-	issueChainString := chain.ResetCurrent(item.ChainIssue).RenderMarkdown()
+// prepareChain fetches the items of the chain and applies the render options,
+// returning the chain as it would be written to each item.
+func prepareChain(client *GhClient, chain Chain, opts syncOptions) (Chain, prefetched, error) {
+	issues := make([]ChainIssue, 0, len(chain.Items))
+	for _, item := range chain.Items {
+		if item.Number != 0 {
+			issues = append(issues, item.ChainIssue)
+		}
+	}
+	fetched := prefetched(client.FetchIssues(issues))
+
+	chain.Items = slices.Clone(chain.Items)
+	for i, item := range chain.Items {
+		if issue, ok := fetched[item.Key()]; ok {
+			chain.Items[i].IsPullRequest = issue.IsPull()
+		}
+	}
+
+	if chain.Header == "" {
+		chain.Header = opts.Header
+	}
+	if opts.ListStyle != KeepListStyle {
+		chain = chain.WithListStyle(opts.ListStyle)
+	}
+	if !opts.Titles {
+		chain = chain.WithoutTitles()
+	}
 
-	itemIssue, err := client.GetIssue(item.ChainIssue)
+	// templates from the repo config were read from the repo when it was loaded
+	tmpl := opts.RepoTemplate
+	var err error
+	if tmpl == nil {
+		tmpl, err = loadTemplate(opts.TemplatePath)
+	}
 	if err != nil {
-		return "error", fmt.Errorf("error retrieving item %d: %w", item.Number, err)
+		return Chain{}, nil, fmt.Errorf("error loading template: %w", err)
+	}
+
+	if opts.Badges != NoBadges || opts.SyncCheckboxes || opts.Titles || opts.Mermaid || tmpl != nil {
+		// templates always get the titles so they can render them however they like
+		if chain, err = fetchIssueDetails(client, chain, fetched, opts.Titles || tmpl != nil, opts.Concurrency); err != nil {
+			return Chain{}, nil, err
+		}
+		chain = chain.WithBadges(opts.Badges)
 	}
 
-	updatedBody := ReplaceChain(itemIssue.Body, issueChainString)
-	if updatedBody == itemIssue.Body {
-		return "skipped", nil
+	if opts.Mermaid {
+		chain = chain.WithMermaid()
 	}
 
-	if err := client.UpdateIssueBody(item.ChainIssue, updatedBody); err != nil {
-		return "error", fmt.Errorf("error updating item %d: %w", item.Number, err)
+	if tmpl != nil {
+		chain = chain.WithTemplate(tmpl)
 	}
 
-	return "updated", nil
+	if opts.SyncCheckboxes {
+		chain = chain.SyncCheckboxes()
+	}
+
+	if err := checkTemplate(chain); err != nil {
+		return Chain{}, nil, err
+	}
+	return chain, fetched, nil
 }
 
-func getTargetIssue(args []string) ChainIssue {
-	currentRepo, _ := repository.Current()
-	// use first argument
-	if len(args) >= 1 {
-		issueRef := args[0]
+// checkTemplate renders the chain for the source and every item, so a template
+// that fails for one of them fails before anything is updated.
+func checkTemplate(chain Chain) error {
+	if chain.template == nil {
+		return nil
+	}
+	for _, member := range members(chain) {
+		if _, err := chain.ResetCurrent(member).renderTemplate(); err != nil {
+			return fmt.Errorf("error rendering template for %s: %w", member.Ref(), err)
+		}
+	}
+	return nil
+}
 
-		// current repo reference if number only
-		if _, err := strconv.Atoi(issueRef); err == nil {
-			issueRef = "#" + issueRef
+// prefetched holds issues retrieved up front, keyed by ChainIssue.Key.
+type prefetched map[ChainIssue]IssueResponse
+
+// get returns the prefetched issue, falling back to the REST API.
+func (p prefetched) get(client *GhClient, issue ChainIssue) (IssueResponse, error) {
+	if response, ok := p[issue.Key()]; ok {
+		return response, nil
+	}
+	return client.GetIssue(issue.Key())
+}
+
+// maxConflictRetries is how many times a body that changed during the sync is
+// re-fetched and the chain re-applied before giving up.
+const maxConflictRetries = 3
+
+var ErrConflict = errors.New("body kept changing while updating")
+
+func updateIssue(client *GhClient, chain Chain, item ChainItem, fetched prefetched, opts syncOptions) (string, string, error) {
+	// items without a ref, such as group headings in nested chains, have nothing to update
+	if item.Number == 0 {
+		return "skipped", "", nil
+	}
+
+	itemIssue, err := fetched.get(client, item.ChainIssue)
+	if err != nil {
+		return "error", "", fmt.Errorf("error retrieving item %d: %w", item.Number, err)
+	}
+
+	item.IsPullRequest = itemIssue.IsPull()
+	// update the CurrentLocationIndicator to the current issue
+	issueChainString := chain.ResetCurrent(item.ChainIssue).RenderMarkdown()
+
+	return editBody(client, item, itemIssue, opts, func(body string) string {
+		return ReplaceChainAt(body, issueChainString, opts.Insert)
+	})
+}
+
+// bodyEditor reads and writes the body of an issue or pull request.
+type bodyEditor interface {
+	GetIssue(issue ChainIssue) (IssueResponse, error)
+	UpdateIssueBody(issue ChainIssue, body string) error
+}
+
+// editBody writes the body of the item with edit applied, unless it is
+// unchanged. The edit is re-applied if the body changes while it is written.
+// Only the body is compared, as comments, pushes and labels also change when
+// an issue was updated.
+func editBody(client bodyEditor, item ChainItem, itemIssue IssueResponse, opts syncOptions, edit func(body string) string) (string, string, error) {
+	for attempt := 0; ; attempt++ {
+		updatedBody := edit(itemIssue.Body)
+		if updatedBody == itemIssue.Body {
+			return "skipped", "", nil
 		}
 
-		issue := issueFromString(issueRef)
-		// Argument was a URL
-		if issue.Number != 0 && issue.Repo.Host != "" {
-			return issue
+		if opts.DryRun {
+			return "changed", UnifiedDiff(item.URL(), item.URL(), itemIssue.Body, updatedBody), nil
 		}
 
-		// Argument was a number
-		issue.Repo = currentRepo
-		if issue.Number != 0 && issue.Repo.Host != "" {
-			return issue
+		// check the body was not edited since it was read so the edit is not overwritten
+		latest, err := client.GetIssue(item.Key())
+		if err != nil {
+			return "error", "", fmt.Errorf("error retrieving item %d: %w", item.Number, err)
+		}
+		if latest.Body == itemIssue.Body {
+			if err := client.UpdateIssueBody(item.ChainIssue, updatedBody); err != nil {
+				return "error", "", fmt.Errorf("error updating item %d: %w", item.Number, err)
+			}
+			return "updated", "", nil
 		}
 
-		// bad argument
-		return ChainIssue{}
+		if attempt == maxConflictRetries {
+			return "conflict", "", fmt.Errorf("%w: item %d", ErrConflict, item.Number)
+		}
+		itemIssue = latest
+	}
+}
+
+// loadChain parses the chain with the given name from the body of the issue ref
+// in args, or the first chain if the name is empty.
+func loadChain(client *GhClient, args []string, name string) (*Chain, error) {
+	targetIssue := getTargetIssue(args)
+	if targetIssue.Number == 0 {
+		return nil, ErrNoIssueRef
+	}
+
+	issue, err := client.GetIssue(targetIssue)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNamed(targetIssue, issue.Body, name)
+}
+
+func getTargetIssue(args []string) ChainIssue {
+	currentRepo, _ := repository.Current()
+	// use first argument
+	if len(args) >= 1 {
+		return resolveRef(currentRepo, args[0])
 	}
 
 	// detect from branch
@@ -137,6 +448,25 @@ func getTargetIssue(args []string) ChainIssue {
 	return issueFromMessage(currentRepo, jsonResp.CurrentBranch.Url)
 }
 
+// resolveRef returns the issue for a number, shorthand ref or url. Numbers and
+// shorthand refs are relative to repo. A zero ChainIssue is returned for a bad ref.
+func resolveRef(repo repository.Repository, ref string) ChainIssue {
+	// current repo reference if number only
+	if _, err := strconv.Atoi(ref); err == nil {
+		ref = "#" + ref
+	}
+
+	issue := issueFromMessage(repo, ref)
+	if issue.Repo.Host == "" {
+		// outside a repo an owner/repo#123 ref is on the default host
+		issue.Repo.Host, _ = auth.DefaultHost()
+	}
+	if issue.Number == 0 || issue.Repo.Owner == "" || issue.Repo.Name == "" {
+		return ChainIssue{}
+	}
+	return issue
+}
+
 func must[T any](v T, err error) T {
 	if err != nil {
 		panic(err)
@@ -151,26 +481,69 @@ func must0(err error) {
 }
 
 type responseMsg struct {
-	index  int
-	result string
-	err    error
+	index    int
+	result   string
+	diff     string
+	err      error
+	duration time.Duration
+}
+
+// syncItems updates every item in the chain, calling onResponse as each one completes.
+func syncItems(client *GhClient, chain Chain, fetched prefetched, opts syncOptions, onResponse func(responseMsg)) {
+	p := pool.New().WithMaxGoroutines(opts.Concurrency)
+	for i, item := range syncTargets(chain, opts) {
+		i, item := i, item
+		p.Go(func() {
+			start := time.Now()
+			resp, diff, err := updateIssue(client, chain, item, fetched, opts)
+			onResponse(responseMsg{index: i, result: resp, diff: diff, err: err, duration: time.Since(start)})
+		})
+	}
+	p.Wait()
+}
+
+// fetchIssueDetails sets the live IssueState of every item in the chain, and the
+// live title if titles is set.
+func fetchIssueDetails(client *GhClient, chain Chain, fetched prefetched, titles bool, concurrency int) (Chain, error) {
+	newChain := chain
+	newChain.Items = slices.Clone(chain.Items)
+
+	p := pool.New().WithErrors().WithMaxGoroutines(concurrency)
+	for i, item := range newChain.Items {
+		i, item := i, item
+		p.Go(func() error {
+			if item.Number == 0 {
+				return nil
+			}
+			issue, err := fetched.get(client, item.ChainIssue)
+			if err != nil {
+				return fmt.Errorf("error retrieving item %d: %w", item.Number, err)
+			}
+			newChain.Items[i].IssueState = issue.IssueState()
+			if titles {
+				newChain.Items[i].Title = issue.Title
+			}
+			return nil
+		})
+	}
+	return newChain, p.Wait()
 }
 
 func (m model) updatePRs() tea.Cmd {
 	return func() tea.Msg {
-		p := pool.New().WithMaxGoroutines(5)
-		for i, item := range m.chain.Items {
-			i, item := i, item
-			p.Go(func() {
-				resp, err := updateIssue(m.gh, m.chain, item)
-				m.sub <- responseMsg{index: i, result: resp, err: err}
-			})
-		}
-		p.Wait()
+		syncItems(m.gh, m.chain, m.fetched, m.opts, func(msg responseMsg) {
+			m.sub <- msg
+		})
 		return nil
 	}
 }
 
+// waitingMsg is sent when a request is waiting to be retried after a rate limit or server error.
+type waitingMsg struct {
+	until time.Time
+	err   error
+}
+
 // A command that waits for the activity on a channel.
 func waitForActivity(sub chan responseMsg) tea.Cmd {
 	return func() tea.Msg {
@@ -183,6 +556,9 @@ type model struct {
 	sub       chan responseMsg
 	responses map[int]responseMsg
 	chain     Chain
+	fetched   prefetched
+	opts      syncOptions
+	waiting   waitingMsg
 }
 
 func (m model) Init() tea.Cmd {
@@ -198,10 +574,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, tea.Quit
 	case responseMsg:
 		m.responses[v.index] = v
-		if len(m.responses) == len(m.chain.Items) {
+		if len(m.responses) == len(syncTargets(m.chain, m.opts)) {
 			return m, tea.Quit
 		}
 		return m, waitForActivity(m.sub) // wait for next event
+	case waitingMsg:
+		m.waiting = v
+		return m, nil
 	default:
 		return m, nil
 	}
@@ -212,19 +591,50 @@ func (m model) View() string {
 	if m.chain.Header != "" {
 		_, _ = fmt.Fprintln(sb, blue(m.chain.Header))
 	}
-	for i, item := range m.chain.Items {
+	for i, item := range syncTargets(m.chain, m.opts) {
 		if response, ok := m.responses[i]; ok {
-			switch response.result {
-			case "updated":
-				_, _ = fmt.Fprintln(sb, green("✓"), item.renderListPoint(i), item.Message)
-			case "skipped":
-				_, _ = fmt.Fprintln(sb, yellow("∅"), item.renderListPoint(i), item.Message)
-			case "error":
-				_, _ = fmt.Fprintln(sb, red("✗"), item.renderListPoint(i), item.Message, red(response.err))
-			}
+			renderResponse(sb, targetPoint(m.chain, i), item, response)
 		} else {
-			_, _ = fmt.Fprintln(sb, hiBlack("_"), item.renderListPoint(i), item.Message)
+			_, _ = fmt.Fprintln(sb, hiBlack("_"), targetPoint(m.chain, i), item.Message)
 		}
 	}
+	if time.Now().Before(m.waiting.until) {
+		_, _ = fmt.Fprintln(sb, yellow("⧗ waiting for rate limit until", m.waiting.until.Format(time.TimeOnly)), hiBlack(m.waiting.err))
+	}
 	return sb.String()
 }
+
+func renderResponse(w io.Writer, point string, item ChainItem, response responseMsg) {
+	switch response.result {
+	case "updated":
+		_, _ = fmt.Fprintln(w, green("✓"), point, item.Message)
+	case "changed":
+		_, _ = fmt.Fprintln(w, blue("~"), point, item.Message)
+		_, _ = fmt.Fprintln(w, colorDiff(response.diff))
+	case "skipped":
+		_, _ = fmt.Fprintln(w, yellow("∅"), point, item.Message)
+	case "removed":
+		_, _ = fmt.Fprintln(w, red("−"), point, item.Message, hiBlack("chain removed"))
+	case "conflict":
+		_, _ = fmt.Fprintln(w, red("≠"), point, item.Message, red(response.err))
+	case "error":
+		_, _ = fmt.Fprintln(w, red("✗"), point, item.Message, red(response.err))
+	}
+}
+
+func colorDiff(diff string) string {
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			lines[i] = bold(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = blue(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = green(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = red(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEditor returns each of the bodies in turn from GetIssue, repeating the
+// last one, and records the bodies written.
+type fakeEditor struct {
+	bodies  []string
+	reads   int
+	written []string
+	err     error
+}
+
+func (f *fakeEditor) GetIssue(ChainIssue) (IssueResponse, error) {
+	body := f.bodies[min(f.reads, len(f.bodies)-1)]
+	f.reads++
+	// activity on the issue changes when it was updated on every read
+	return IssueResponse{Body: body, UpdatedAt: time.Now().Add(time.Duration(f.reads) * time.Second)}, f.err
+}
+
+func (f *fakeEditor) UpdateIssueBody(_ ChainIssue, body string) error {
+	f.written = append(f.written, body)
+	return nil
+}
+
+func TestEditBody(t *testing.T) {
+	appendChain := func(body string) string {
+		return strings.TrimSuffix(body, "\nchain") + "\nchain"
+	}
+	changing := []string{"edited 1", "edited 2", "edited 3", "edited 4", "edited 5"}
+
+	tests := map[string]struct {
+		body        string
+		latest      []string
+		dryRun      bool
+		err         error
+		want        string
+		wantWritten []string
+		wantErr     error
+	}{
+		"Unchanged": {
+			body:        "body",
+			latest:      []string{"body"},
+			want:        "updated",
+			wantWritten: []string{"body\nchain"},
+		},
+		"OnlyUpdatedAtChanged": {
+			body:        "body",
+			latest:      []string{"body", "body"},
+			want:        "updated",
+			wantWritten: []string{"body\nchain"},
+		},
+		"EditedWhileSyncing": {
+			body:        "body",
+			latest:      []string{"edited", "edited"},
+			want:        "updated",
+			wantWritten: []string{"edited\nchain"},
+		},
+		"KeepsChanging": {
+			body:    "body",
+			latest:  changing,
+			want:    "conflict",
+			wantErr: ErrConflict,
+		},
+		"AlreadyUpToDate": {
+			body:   "body\nchain",
+			latest: []string{"unused"},
+			want:   "skipped",
+		},
+		"DryRun": {
+			body:   "body",
+			latest: []string{"unused"},
+			dryRun: true,
+			want:   "changed",
+		},
+		"ReadFails": {
+			body:    "body",
+			latest:  []string{"body"},
+			err:     errors.New("boom"),
+			want:    "error",
+			wantErr: errors.New("boom"),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			editor := &fakeEditor{bodies: tt.latest, err: tt.err}
+			item := ChainItem{ChainIssue: TestIssue}
+
+			result, _, err := editBody(editor, item, IssueResponse{Body: tt.body}, syncOptions{DryRun: tt.dryRun}, appendChain)
+			assert.Equal(t, tt.want, result)
+			switch {
+			case tt.wantErr == nil:
+				assert.NoError(t, err)
+			case errors.Is(tt.wantErr, ErrConflict):
+				assert.ErrorIs(t, err, ErrConflict)
+				assert.Equal(t, maxConflictRetries+1, editor.reads)
+			default:
+				assert.ErrorContains(t, err, tt.wantErr.Error())
+			}
+			assert.Equal(t, tt.wantWritten, editor.written)
+		})
+	}
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+// document is a markdown body parsed into its top level blocks. Blocks keep the
+// lines they span so the body can be rewritten line by line, leaving everything
+// outside the chain exactly as it was.
+type document struct {
+	source     []byte
+	lines      []string
+	lineStarts []int
+	blocks     []mdBlock
+}
+
+// mdBlock is a top level block and the first and last line it spans.
+type mdBlock struct {
+	node       ast.Node
+	start, end int
+}
+
+// chainBlock is where a chain is in a document.
+type chainBlock struct {
+	// Indicator is the line of the chainlink comment.
+	Indicator reMatch
+	// Header is the line of the heading right above the indicator, with a
+	// LineNumber of -1 if there is none.
+	Header reMatch
+	// End is the last line of the chain, which is the end indicator of templated
+	// chains or the line of the last item, or -1 if the indicator has no list.
+	// Text continuing the last item is not part of the chain, so it is kept.
+	End int
+	// HasEnd reports whether the chain is closed by an end indicator.
+	HasEnd bool
+
+	// content are the blocks between the indicator and the end.
+	content []mdBlock
+}
+
+// Start returns the first line of the chain, including its header if includeHeader is set.
+func (c chainBlock) Start(includeHeader bool) int {
+	if includeHeader && c.Header.LineNumber >= 0 {
+		return c.Header.LineNumber
+	}
+	return c.Indicator.LineNumber
+}
+
+func parseDocument(content string) *document {
+	d := &document{
+		source: []byte(content),
+		lines:  strings.Split(content, "\n"),
+	}
+	start := 0
+	for _, line := range d.lines {
+		d.lineStarts = append(d.lineStarts, start)
+		start += len(line) + 1
+	}
+
+	root := goldmark.DefaultParser().Parse(text.NewReader(d.source))
+	for n := root.FirstChild(); n != nil; n = n.NextSibling() {
+		start, end := d.blockLines(n)
+		if start < 0 {
+			continue
+		}
+		d.blocks = append(d.blocks, mdBlock{node: n, start: start, end: end})
+	}
+	return d
+}
+
+// lineOf returns the line the byte offset is on.
+func (d *document) lineOf(offset int) int {
+	return sort.Search(len(d.lineStarts), func(i int) bool {
+		return d.lineStarts[i] > offset
+	}) - 1
+}
+
+// blockLines returns the first and last line of the block, or -1 if it has no lines.
+func (d *document) blockLines(n ast.Node) (int, int) {
+	start, end := -1, -1
+	include := func(line int) {
+		if start < 0 || line < start {
+			start = line
+		}
+		end = max(end, line)
+	}
+
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering || c.Type() != ast.TypeBlock {
+			return ast.WalkContinue, nil
+		}
+		lines := c.Lines()
+		for i := 0; i < lines.Len(); i++ {
+			include(d.lineOf(lines.At(i).Start))
+		}
+
+		switch c := c.(type) {
+		case *ast.HTMLBlock:
+			if c.HasClosure() {
+				include(d.lineOf(c.ClosureLine.Start))
+			}
+		case *ast.Heading:
+			// the underline of a setext heading is not one of its lines
+			if lines.Len() > 0 && !headerRE.MatchString(d.lines[d.lineOf(lines.At(0).Start)]) {
+				include(end + 1)
+			}
+		case *ast.FencedCodeBlock:
+			// the fences are not lines of the code block
+			if c.Info != nil {
+				include(d.lineOf(c.Info.Segment.Start))
+			} else if lines.Len() > 0 {
+				include(d.lineOf(lines.At(0).Start) - 1)
+			}
+			if last := end + 1; last < len(d.lines) && isFence(d.lines[last]) {
+				include(last)
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return start, end
+}
+
+func isFence(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// indicatorLine returns the line of the chainlink comment in the block, or -1.
+// Comments inside code, block quotes and lists are not indicators.
+func (d *document) indicatorLine(b mdBlock, re *regexp.Regexp) int {
+	switch b.node.(type) {
+	case *ast.HTMLBlock, *ast.Paragraph:
+		for line := b.start; line <= b.end; line++ {
+			if re.MatchString(d.lines[line]) {
+				return line
+			}
+		}
+	}
+	return -1
+}
+
+// chains returns every chain in the document in the order they appear.
+func (d *document) chains() []chainBlock {
+	var chains []chainBlock
+	for i, b := range d.blocks {
+		line := d.indicatorLine(b, indicatorRE)
+		if line < 0 {
+			continue
+		}
+
+		chain := chainBlock{
+			Indicator: reMatch{LineNumber: line, Raw: d.lines[line]},
+			Header:    reMatch{LineNumber: -1},
+			End:       -1,
+		}
+		if i > 0 {
+			if _, ok := d.blocks[i-1].node.(*ast.Heading); ok && headerRE.MatchString(d.lines[d.blocks[i-1].start]) {
+				chain.Header = reMatch{LineNumber: d.blocks[i-1].start, Raw: d.lines[d.blocks[i-1].start]}
+			}
+		}
+
+		// a templated chain is everything up to the end indicator, as long as
+		// another chain does not start first
+		for j := i + 1; j < len(d.blocks) && d.indicatorLine(d.blocks[j], indicatorRE) < 0; j++ {
+			if end := d.indicatorLine(d.blocks[j], endIndicatorRE); end >= 0 {
+				chain.End, chain.HasEnd, chain.content = end, true, d.blocks[i+1:j]
+				break
+			}
+		}
+
+		// otherwise it is the list right after the indicator, which may be split
+		// into several lists when the list points change
+		if !chain.HasEnd {
+			for j := i + 1; j < len(d.blocks); j++ {
+				if _, ok := d.blocks[j].node.(*ast.List); !ok || (j > i+1 && d.blocks[j].start != d.blocks[j-1].end+1) {
+					break
+				}
+				chain.End, chain.content = d.lastItemLine(d.blocks[j]), d.blocks[i+1:j+1]
+			}
+		}
+		chains = append(chains, chain)
+	}
+	return chains
+}
+
+// lastItemLine returns the first line of the last item in the list, including
+// the items nested in it, or the first line of the list if it has no items.
+func (d *document) lastItemLine(list mdBlock) int {
+	last := list.start
+	_ = ast.Walk(list.node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if _, ok := n.(*ast.ListItem); !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		if first := n.FirstChild(); first != nil && first.Lines().Len() > 0 {
+			last = max(last, d.lineOf(first.Lines().At(0).Start))
+		}
+		return ast.WalkContinue, nil
+	})
+	return last
+}
+
+// raw returns the lines of the chain content.
+func (d *document) raw(c chainBlock) string {
+	if len(c.content) == 0 {
+		return ""
+	}
+	if c.HasEnd {
+		// only the lines that are not code, as those are all that is parsed
+		var raw []string
+		for _, b := range c.content {
+			if _, ok := b.node.(*ast.FencedCodeBlock); ok {
+				continue
+			}
+			if _, ok := b.node.(*ast.CodeBlock); ok {
+				continue
+			}
+			raw = append(raw, d.lines[b.start:b.end+1]...)
+		}
+		return strings.Join(raw, "\n")
+	}
+	return strings.Join(d.lines[c.content[0].start:c.End+1], "\n")
+}
+
+// items returns the items of the chain and the line each one is on. Lists are
+// read item by item, and any other line that contains a ref is read as a
+// numbered item. Templated chains are read from the list kept in their items
+// comment when they have one.
+func (d *document) items(current ChainIssue, c chainBlock) ([]ChainItem, []int) {
+	if start, end, ok := d.storedItems(c); ok {
+		return d.storedList(current, start, end)
+	}
+
+	var items []ChainItem
+	var lines []int
+	for _, b := range c.content {
+		switch node := b.node.(type) {
+		case *ast.List:
+			listItems, listLines := d.listItems(current, node, 0)
+			items, lines = append(items, listItems...), append(lines, listLines...)
+		case *ast.FencedCodeBlock, *ast.CodeBlock:
+			continue
+		default:
+			for i, line := range d.lines[b.start : b.end+1] {
+				if ref := refRE.FindString(line); ref != "" {
+					matches := map[string]string{"Numbered": "1", "Message": ref}
+					items = append(items, newChainItem(current, matches, 0, line))
+					lines = append(lines, b.start+i)
+				}
+			}
+		}
+	}
+	return items, lines
+}
+
+// storedItems returns the first and last line of the items comment of a
+// templated chain. Lines are matched rather than blocks, as the template output
+// before the comment may be a block that runs into it.
+func (d *document) storedItems(c chainBlock) (int, int, bool) {
+	if !c.HasEnd || len(c.content) == 0 {
+		return 0, 0, false
+	}
+	for start := c.content[0].start; start < c.End; start++ {
+		if !itemsRE.MatchString(d.lines[start]) {
+			continue
+		}
+		for end := start + 1; end < c.End; end++ {
+			if strings.TrimSpace(d.lines[end]) == "-->" {
+				return start, end, true
+			}
+		}
+	}
+	return 0, 0, false
+}
+
+// storedList reads the list between the first and last line of the items
+// comment, returning the lines in this document.
+func (d *document) storedList(current ChainIssue, start, end int) ([]ChainItem, []int) {
+	list := parseDocument(strings.Join(d.lines[start+1:end], "\n"))
+	var items []ChainItem
+	var lines []int
+	for _, b := range list.blocks {
+		if node, ok := b.node.(*ast.List); ok {
+			listItems, listLines := list.listItems(current, node, 0)
+			items = append(items, listItems...)
+			for _, line := range listLines {
+				lines = append(lines, start+1+line)
+			}
+		}
+	}
+	return items, lines
+}
+
+// listItems returns the items of the list and the lists nested in them, and
+// the line each one is on.
+func (d *document) listItems(current ChainIssue, list *ast.List, depth int) ([]ChainItem, []int) {
+	var items []ChainItem
+	var lines []int
+	for li := list.FirstChild(); li != nil; li = li.NextSibling() {
+		childDepth := depth
+		if first := li.FirstChild(); first != nil && first.Lines().Len() > 0 {
+			segment := first.Lines().At(0)
+			lineNumber := d.lineOf(segment.Start)
+			line := d.lines[lineNumber]
+			matches, ok := FindMatchGroups(itemRE, line)
+			if !ok {
+				// other list points are read as the closest one chainlink renders
+				matches = map[string]string{
+					"Message":  strings.TrimSpace(string(segment.Value(d.source))),
+					"Numbered": iif(list.IsOrdered(), "1", ""),
+				}
+			}
+			items = append(items, newChainItem(current, matches, depth, line))
+			lines = append(lines, lineNumber)
+			childDepth++
+		}
+
+		for child := li.FirstChild(); child != nil; child = child.NextSibling() {
+			if nested, ok := child.(*ast.List); ok {
+				nestedItems, nestedLines := d.listItems(current, nested, childDepth)
+				items, lines = append(items, nestedItems...), append(lines, nestedLines...)
+			}
+		}
+	}
+	return items, lines
+}
+
+func newChainItem(current ChainIssue, matches map[string]string, depth int, raw string) ChainItem {
+	message, title := splitTitle(parseMessage(matches))
+	issue := issueFromMessage(current.Repo, message)
+	return ChainItem{
+		ChainIssue: issue,
+		Depth:      depth,
+		IsCurrent:  issue == current,
+		Message:    message,
+		Title:      title,
+		ItemState:  parseItemState(matches),
+		Raw:        raw,
+	}
+}
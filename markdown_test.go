@@ -0,0 +1,100 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	tests := map[string]struct {
+		content string
+		want    []string
+		wantErr error
+	}{
+		"IndicatorInCodeFence": {
+			content: "```markdown\n<!-- chainlink -->\n- #9\n```\n\n<!-- chainlink -->\n- #1\n- #2",
+			want:    []string{"#1", "#2"},
+		},
+		"OnlyIndicatorInCodeFence": {
+			content: "```\n<!-- chainlink -->\n- #9\n```",
+			wantErr: ErrNotFound,
+		},
+		"CodeSampleAfterChain": {
+			content: "<!-- chainlink -->\n1. #1\n2. #2\n\n```yaml\n- name: build\n- #3\n```",
+			want:    []string{"#1", "#2"},
+		},
+		"ListInBlockQuote": {
+			content: "> <!-- chainlink -->\n> - #9\n\n<!-- chainlink -->\n- #1",
+			want:    []string{"#1"},
+		},
+		"ParagraphRightAfterList": {
+			content: "<!-- chainlink -->\n1. #1\n2. #2\nSee also #5",
+			want:    []string{"#1", "#2"},
+		},
+		"TextBetweenIndicatorAndList": {
+			content: "<!-- chainlink -->\n\nSome text.\n\n- #1",
+			wantErr: ErrNotFound,
+		},
+		"ChangingListPoints": {
+			content: "<!-- chainlink -->\n1. #1\n- #2\n* #3",
+			want:    []string{"#1", "#2", "#3"},
+		},
+		"ListAfterBlankLineIsNotPartOfChain": {
+			content: "<!-- chainlink -->\n- #1\n\n1. unrelated #5",
+			want:    []string{"#1"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			chain, err := Parse(TestIssue, tt.content)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			var messages []string
+			for _, item := range chain.Items {
+				messages = append(messages, item.Message)
+			}
+			assert.Equal(t, tt.want, messages)
+		})
+	}
+}
+
+func TestReplaceChainMarkdown(t *testing.T) {
+	chain := "<!--chainlink-->\n1. #1 &larr; you are here\n2. #2"
+	tests := map[string]struct {
+		body string
+		want string
+	}{
+		"IndicatorInCodeFence": {
+			body: "Example:\n```\n<!--chainlink-->\n- #9\n```",
+			want: "Example:\n```\n<!--chainlink-->\n- #9\n```\n" + chain,
+		},
+		"CodeSampleAfterChain": {
+			body: "<!--chainlink-->\n1. #1\n\n```\n- #3\n```",
+			want: chain + "\n\n```\n- #3\n```",
+		},
+		"ParagraphRightAfterList": {
+			body: "<!--chainlink-->\n1. #1\n2. #2\nSome paragraph right after.\n\nAfter.",
+			want: chain + "\nSome paragraph right after.\n\nAfter.",
+		},
+		"ParagraphRightAfterNestedList": {
+			body: "<!--chainlink-->\n1. #1\n   - #2\nSome paragraph right after.",
+			want: chain + "\nSome paragraph right after.",
+		},
+		"PreservesSurroundingBytes": {
+			body: "Intro  \r\n\r\n<!--chainlink-->\n1. #1\n\nTrailing\ttabs \n",
+			want: "Intro  \r\n\r\n" + chain + "\n\nTrailing\ttabs \n",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := ReplaceChain(tt.body, chain)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, got, ReplaceChain(got, chain), "replacing again changes nothing")
+		})
+	}
+}
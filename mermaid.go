@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mermaidClasses styles the nodes by IssueState, and the current item.
+var mermaidClasses = []string{
+	"classDef open fill:#dafbe1,stroke:#1a7f37,color:#1f2328",
+	"classDef draft fill:#eaeef2,stroke:#6e7781,color:#1f2328",
+	"classDef merged fill:#fbefff,stroke:#8250df,color:#1f2328",
+	"classDef closed fill:#ffebe9,stroke:#cf222e,color:#1f2328",
+	"classDef current stroke-width:4px,font-weight:bold",
+}
+
+// WithMermaid renders a mermaid graph of the items under the list.
+func (c Chain) WithMermaid() Chain {
+	newChain := c
+	newChain.mermaid = true
+	return newChain
+}
+
+// renderMermaid renders the items as nodes of a mermaid graph joined in chain
+// order, styled by state with the current item highlighted.
+func (c Chain) renderMermaid() string {
+	lines := []string{"```mermaid", "graph LR"}
+	var classes []string
+	for i, item := range c.Items {
+		node := fmt.Sprint("n", i)
+		lines = append(lines, fmt.Sprintf("  %s[\"%s\"]", node, c.mermaidLabel(item)))
+		if i > 0 {
+			lines = append(lines, fmt.Sprintf("  n%d --> %s", i-1, node))
+		}
+		if item.IssueState != StateUnknown {
+			classes = append(classes, fmt.Sprintf("  class %s %s", node, item.IssueState))
+		}
+		if item.IsCurrent {
+			classes = append(classes, fmt.Sprintf("  class %s current", node))
+		}
+	}
+	for _, class := range mermaidClasses {
+		lines = append(lines, "  "+class)
+	}
+	lines = append(lines, classes...)
+	lines = append(lines, "```")
+	return strings.Join(lines, "\n")
+}
+
+// mermaidLabel returns the ref of the item, short for items in the source repo,
+// followed by the title if there is one.
+func (c Chain) mermaidLabel(item ChainItem) string {
+	label := item.Message
+	if item.Number != 0 {
+		label = iif(item.Repo == c.Source.Repo, fmt.Sprint("#", item.Number), item.Ref())
+	}
+	if item.Title != "" {
+		label += "<br>" + item.Title
+	}
+	return strings.ReplaceAll(label, `"`, "#quot;")
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain_RenderMermaid(t *testing.T) {
+	other := ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "RoryQ", Name: "other"}, Number: 7}
+	chain := Chain{
+		Source: repoIssue(1),
+		Items: []ChainItem{
+			{ChainIssue: repoIssue(1), Message: "#1", ItemState: Numbered, IssueState: StateMerged},
+			{ChainIssue: repoIssue(2), Message: "#2", ItemState: Numbered, IssueState: StateOpen, Title: `Add "v2" parser`},
+			{ChainIssue: other, Message: other.URL(), ItemState: Numbered, IssueState: StateDraft},
+		},
+	}
+
+	expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=c0f9dcbe --> 
+1. #1 
+2. #2 — Add "v2" parser &larr; you are here 
+3. https://github.com/RoryQ/other/issues/7
+
+` + "```mermaid" + `
+graph LR
+  n0["#1"]
+  n1["#2<br>Add #quot;v2#quot; parser"]
+  n0 --> n1
+  n2["RoryQ/other#7"]
+  n1 --> n2
+  classDef open fill:#dafbe1,stroke:#1a7f37,color:#1f2328
+  classDef draft fill:#eaeef2,stroke:#6e7781,color:#1f2328
+  classDef merged fill:#fbefff,stroke:#8250df,color:#1f2328
+  classDef closed fill:#ffebe9,stroke:#cf222e,color:#1f2328
+  classDef current stroke-width:4px,font-weight:bold
+  class n0 merged
+  class n1 open
+  class n1 current
+  class n2 draft
+` + "```" + `
+<!-- /chainlink -->`
+	rendered := chain.WithMermaid().ResetCurrent(repoIssue(2)).RenderMarkdown()
+	assert.Equal(t, expected, rendered)
+
+	t.Run("Reparse", func(t *testing.T) {
+		parsed, err := Parse(repoIssue(2), "Description\n\n"+rendered+"\n\nMore text")
+		assert.NoError(t, err)
+
+		var refs []string
+		for _, item := range parsed.Items {
+			refs = append(refs, item.Ref())
+		}
+		assert.Equal(t, []string{"RoryQ/gh-chainlink#1", "RoryQ/gh-chainlink#2", "RoryQ/other#7"}, refs)
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		updated := chain.WithMermaid().ResetCurrent(repoIssue(1)).RenderMarkdown()
+		body := "Description\n\n" + rendered + "\n\nMore text"
+		assert.Equal(t, "Description\n\n"+updated+"\n\nMore text", ReplaceChain(body, updated))
+	})
+
+	t.Run("ReplaceList", func(t *testing.T) {
+		list := chain.ResetCurrent(repoIssue(2)).RenderMarkdown()
+		body := "Description\n\n" + list + "\n\nMore text"
+		assert.Equal(t, "Description\n\n"+rendered+"\n\nMore text", ReplaceChain(body, rendered))
+	})
+}
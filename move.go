@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+)
+
+var ErrAmbiguousMove = errors.New("item is in the chain more than once")
+
+func moveCommand(client *GhClient, args []string) error {
+	fs := flag.NewFlagSet("move", flag.ExitOnError)
+	to := fs.Int("to", 0, "Move the item to this 1-based `position` in the chain.")
+	name := fs.String("chain", "", "Name of the chain to reorder when the body has more than one.")
+	retarget := fs.Bool("retarget", false, "Also set the base branch of each pull request to match the new order.")
+	yes := fs.Bool("yes", false, "Retarget without asking for confirmation.")
+	opts := addSyncFlags(fs)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: gh chainlink move [flags] --to <position> <chain ref> <item ref>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 || *to == 0 {
+		fs.Usage()
+		return errors.New("move needs a chain ref, an item ref and --to")
+	}
+
+	chainRef := getTargetIssue(fs.Args()[:1])
+	if chainRef.Number == 0 {
+		return ErrNoIssueRef
+	}
+	if err := configure(client, fs, opts, chainRef.Repo); err != nil {
+		return err
+	}
+
+	chain, err := loadChain(client, fs.Args()[:1], *name)
+	if err != nil {
+		return err
+	}
+
+	moved, err := moveItem(*chain, fs.Arg(1), *to)
+	if err != nil {
+		return err
+	}
+
+	// the source may not be one of the items, so it is written as well
+	opts.UpdateSource = true
+	if err := runSync(client, moved, *opts); err != nil {
+		return err
+	}
+	if *retarget {
+		return retargetChain(client, moved, *yes, opts.DryRun)
+	}
+	return nil
+}
+
+// moveItem moves the item for the ref and the items nested under it to the
+// 1-based position, taking the depth of the item it moves down.
+func moveItem(chain Chain, ref string, position int) (Chain, error) {
+	issue := resolveRef(chain.Source.Repo, ref)
+	index := chain.Index(issue)
+	if issue.Number == 0 || index < 0 {
+		return Chain{}, fmt.Errorf("%w: %s", ErrNotInChain, ref)
+	}
+	count := 0
+	for _, item := range chain.Items {
+		count += iif(item.Number != 0 && item.IsSame(issue), 1, 0)
+	}
+	if count > 1 {
+		return Chain{}, fmt.Errorf("%w: %s", ErrAmbiguousMove, issue.Ref())
+	}
+
+	to, depth, err := insertPosition(chain.withoutSubtree(index), "", "", position)
+	if err != nil {
+		return Chain{}, err
+	}
+	return chain.MoveItem(index, to, depth), nil
+}
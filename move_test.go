@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoveItem(t *testing.T) {
+	chain, err := Parse(TestIssue, `<!--chainlink-->
+1. #1
+2. #2
+   - [x] #3
+   - [ ] #4
+3. #5`)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		chain    *Chain
+		ref      string
+		position int
+		want     string
+		wantErr  string
+	}{
+		"Up": {
+			ref:      "#5",
+			position: 2,
+			want: `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=8f487cc2 --> 
+1. #1 &larr; you are here 
+2. #5 
+3. #2 
+   - [x] #3 
+   - [ ] #4`,
+		},
+		"DownWithNested": {
+			ref:      "#2",
+			position: 3,
+			want: `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=8f487cc2 --> 
+1. #1 &larr; you are here 
+2. #5 
+3. #2 
+   - [x] #3 
+   - [ ] #4`,
+		},
+		"NestedToTop": {
+			ref:      "#4",
+			position: 1,
+			want: `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=09ac0d27 --> 
+- [ ] #4 
+2. #1 &larr; you are here 
+3. #2 
+   - [x] #3 
+4. #5`,
+		},
+		"OutOfRange": {
+			ref:      "#2",
+			position: 4,
+			wantErr:  "position must be between 1 and 3",
+		},
+		"NotInChain": {
+			ref:      "#9",
+			position: 1,
+			wantErr:  "item is not in the chain: #9",
+		},
+		"Duplicate": {
+			chain: func() *Chain {
+				c, _ := Parse(TestIssue, "<!--chainlink-->\n1. #1\n2. #2\n3. #1")
+				return c
+			}(),
+			ref:      "#1",
+			position: 2,
+			wantErr:  "item is in the chain more than once: RoryQ/gh-chainlink#1",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c := *chain
+			if tt.chain != nil {
+				c = *tt.chain
+			}
+			moved, err := moveItem(c, tt.ref, tt.position)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, moved.ResetCurrent(TestIssue).RenderMarkdown())
+		})
+	}
+}
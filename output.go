@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+var ErrItemsFailed = errors.New("one or more items failed to update")
+
+type itemRecord struct {
+	Type       string `json:"type"`
+	Index      *int   `json:"index,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Result     string `json:"result"`
+	Error      string `json:"error,omitempty"`
+	Diff       string `json:"diff,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+type summaryRecord struct {
+	Type       string         `json:"type"`
+	Source     string         `json:"source"`
+	Total      int            `json:"total"`
+	Results    map[string]int `json:"results"`
+	DurationMs int64          `json:"durationMs"`
+}
+
+// syncTargets returns the items to update, followed by the source when the
+// checkboxes or edits are written back to it but it is not one of the items.
+func syncTargets(chain Chain, opts syncOptions) []ChainItem {
+	if (opts.SyncCheckboxes || opts.UpdateSource) && !chain.Contains(chain.Source) {
+		source := ChainItem{ChainIssue: chain.Source, Message: fmt.Sprint("#", chain.Source.Number)}
+		return append(slices.Clone(chain.Items), source)
+	}
+	return chain.Items
+}
+
+// targetPoint returns the list point of the item at index i of the targets, or
+// "source" for the source.
+func targetPoint(chain Chain, i int) string {
+	if i < len(chain.Items) {
+		return chain.ListPoint(i)
+	}
+	return "source"
+}
+
+// collectResponses syncs the chain and returns the responses in the order of syncTargets.
+func collectResponses(client *GhClient, chain Chain, fetched prefetched, opts syncOptions) []responseMsg {
+	responses := make([]responseMsg, len(syncTargets(chain, opts)))
+	mu := sync.Mutex{}
+	syncItems(client, chain, fetched, opts, func(msg responseMsg) {
+		mu.Lock()
+		defer mu.Unlock()
+		responses[msg.index] = msg
+	})
+	return responses
+}
+
+func runPlain(w io.Writer, client *GhClient, chain Chain, fetched prefetched, opts syncOptions) error {
+	if chain.Header != "" {
+		_, _ = fmt.Fprintln(w, blue(chain.Header))
+	}
+
+	var err error
+	targets := syncTargets(chain, opts)
+	for i, response := range collectResponses(client, chain, fetched, opts) {
+		renderResponse(w, targetPoint(chain, i), targets[i], response)
+		if response.err != nil {
+			err = ErrItemsFailed
+		}
+	}
+	return err
+}
+
+// runJSON syncs the chain and prints a record for each item and a summary,
+// which also counts the results of the removed items already printed.
+func runJSON(w io.Writer, client *GhClient, chain Chain, fetched prefetched, removed map[string]int, opts syncOptions) error {
+	start := time.Now()
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	var err error
+	targets := syncTargets(chain, opts)
+	summary := summaryRecord{
+		Type:    "summary",
+		Source:  chain.Source.URL(),
+		Total:   len(targets),
+		Results: map[string]int{},
+	}
+	for result, count := range removed {
+		summary.Total += count
+		summary.Results[result] += count
+	}
+	for i, response := range collectResponses(client, chain, fetched, opts) {
+		item := targets[i]
+		record := itemRecord{
+			Type:       "item",
+			Index:      &i,
+			Result:     response.result,
+			Diff:       response.diff,
+			DurationMs: response.duration.Milliseconds(),
+		}
+		// group headings in nested chains have no ref
+		if item.Number != 0 {
+			record.Ref, record.URL = item.Ref(), item.URL()
+		}
+		if i >= len(chain.Items) {
+			// the source is not one of the items, so it has no index
+			record.Type, record.Index = "source", nil
+		}
+		if response.err != nil {
+			record.Error = response.err.Error()
+			err = ErrItemsFailed
+		}
+		summary.Results[response.result]++
+		if encErr := encoder.Encode(record); encErr != nil {
+			return encErr
+		}
+	}
+
+	summary.DurationMs = time.Since(start).Milliseconds()
+	if encErr := encoder.Encode(summary); encErr != nil {
+		return encErr
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunOutput(t *testing.T) {
+	color.NoColor = true
+	chain := NewChain([]ChainIssue{repoIssue(1), repoIssue(2)})
+	chain.Items = append(chain.Items, ChainItem{Message: "Rollout", ItemState: Numbered})
+	chain.Items[1].IsPullRequest = true
+	// the source is written but is not one of the items
+	chain.Source = repoIssue(9)
+
+	fetched := prefetched{
+		repoIssue(1): {Body: chain.ResetCurrent(repoIssue(1)).RenderMarkdown()},
+		repoIssue(2): {Body: "Description", PullRequest: &pullRequestRef{}},
+		repoIssue(9): {Body: chain.ResetCurrent(repoIssue(9)).RenderMarkdown()},
+	}
+	opts := syncOptions{DryRun: true, UpdateSource: true, Insert: InsertBottom, Concurrency: 2}
+
+	t.Run("Plain", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, runPlain(buf, nil, chain, fetched, opts))
+
+		lines := strings.Split(buf.String(), "\n")
+		assert.Equal(t, "∅ 1. #1", lines[0])
+		assert.Equal(t, "~ 2. #2", lines[1])
+		assert.Equal(t, "--- https://github.com/RoryQ/gh-chainlink/pull/2", lines[2])
+		assert.Equal(t, []string{"∅ 3. Rollout", "∅ source #9", ""}, lines[len(lines)-3:])
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, runJSON(buf, nil, chain, fetched, map[string]int{"removed": 1}, opts))
+
+		var records []map[string]any
+		decoder := json.NewDecoder(buf)
+		for decoder.More() {
+			record := map[string]any{}
+			assert.NoError(t, decoder.Decode(&record))
+			assert.Contains(t, record, "durationMs")
+			delete(record, "durationMs")
+			records = append(records, record)
+		}
+
+		assert.Len(t, records, 5)
+		assert.NotEmpty(t, records[1]["diff"])
+		delete(records[1], "diff")
+		assert.Equal(t, []map[string]any{
+			{"type": "item", "index": 0.0, "ref": "RoryQ/gh-chainlink#1", "url": "https://github.com/RoryQ/gh-chainlink/issues/1", "result": "skipped"},
+			{"type": "item", "index": 1.0, "ref": "RoryQ/gh-chainlink#2", "url": "https://github.com/RoryQ/gh-chainlink/pull/2", "result": "changed"},
+			{"type": "item", "index": 2.0, "result": "skipped"},
+			{"type": "source", "ref": "RoryQ/gh-chainlink#9", "url": "https://github.com/RoryQ/gh-chainlink/issues/9", "result": "skipped"},
+			{"type": "summary", "source": "https://github.com/RoryQ/gh-chainlink/issues/9", "total": 5.0, "results": map[string]any{"changed": 1.0, "removed": 1.0, "skipped": 3.0}},
+		}, records)
+	})
+}
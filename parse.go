@@ -2,10 +2,10 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"regexp"
 	"slices"
-	"sort"
 	"strconv"
 	"strings"
 
@@ -18,45 +18,167 @@ type reMatch struct {
 }
 
 var (
-	indicatorRE = regexp.MustCompile(`(?i)<!--\s*chainlink(?:\s*| generated from.*)-->`)
-	headerRE    = regexp.MustCompile(`(?im)^ {0,3}#{1,6}\s.*`)
-	itemRE      = regexp.MustCompile(`(?i)^\s{0,4}(- (?P<Checked>\[[ x]])?|(?P<Numbered>\d+)[.] )(:? *)(?P<Message>.*)`)
+	indicatorRE    = regexp.MustCompile(`(?i)<!--\s*chainlink(?:\s+name=(?P<Name>[^\s>]+))?(?:\s*| generated from\s*(?P<Source>[^\s>]*)(?:\s+hash=(?P<Hash>\w+))?.*)-->`)
+	headerRE       = regexp.MustCompile(`(?im)^ {0,3}#{1,6}\s.*`)
+	itemRE         = regexp.MustCompile(`(?i)^(?P<Indent> *)(- (?P<Checked>\[[ x]])?|(?P<Numbered>\d+)[.] )(:? *)(?P<Message>.*)`)
+	endIndicatorRE = regexp.MustCompile(`(?i)<!--\s*/chainlink\s*-->`)
+	itemsRE        = regexp.MustCompile(`(?i)^\s*<!--\s*chainlink-items\s*$`)
+	// refs are a url, owner/repo#123 or repo#123, or #123 or GH-123 in the current repo
+	urlRefRE    = regexp.MustCompile(`https?://(?P<host>[^/\s]+)/(?P<owner>[^/\s]+)/(?P<repo>[^/\s]+)/(?:issues|pull)/(?P<number>\d+)`)
+	repoRefRE   = regexp.MustCompile(`(?:^|[^\w/.-])(?:(?P<owner>[\w.-]+)/)?(?P<repo>[\w.-]+)#(?P<number>\d+)\b`)
+	numberRefRE = regexp.MustCompile(`(?:(?:^|[^\w/.-])#|\bGH-)(?P<number>\d+)\b`)
+	linkRefRE   = regexp.MustCompile(`\[[^\]]*\]\((?P<url>[^)\s]+)\)`)
+	refRE       = regexp.MustCompile(urlRefRE.String() + "|" + repoRefRE.String() + "|" + numberRefRE.String())
+	badgeRE     = regexp.MustCompile("\\s*(?:🟢|⚪|🟣|🔴|`(?:open|draft|merged|closed)`)$")
 	ErrNotFound = errors.New("no chainlink list found")
 )
 
-func Parse(current ChainIssue, content string) (*Chain, error) {
-	indicators := findRE(content, indicatorRE)
-	if len(indicators) == 0 {
-		return nil, ErrNotFound
+// indicator is the name, source and hash parsed from a chainlink comment.
+type indicator struct {
+	Name   string
+	Source string
+	Hash   string
+}
+
+func parseIndicator(raw string) indicator {
+	matches, _ := FindMatchGroups(indicatorRE, raw)
+	return indicator{Name: matches["Name"], Source: matches["Source"], Hash: matches["Hash"]}
+}
+
+// matches reports whether a chain rendered with the other indicator should
+// replace the chain with this indicator. Named chains match by name, unnamed
+// chains match by source unless this indicator was written by hand.
+func (i indicator) matches(other indicator) bool {
+	if i.Name != "" || other.Name != "" {
+		return strings.EqualFold(i.Name, other.Name)
+	}
+	return i.Source == "" || other.Source == "" || issueFromString(i.Source).IsSame(issueFromString(other.Source))
+}
+
+// source returns the issue the chain was generated from, or current if the
+// indicator was written by hand.
+func (i indicator) source(current ChainIssue) ChainIssue {
+	if i.Source == "" {
+		return current
 	}
+	source := issueFromMessage(current.Repo, i.Source)
+	// keep the url as it was rendered so the indicator is unchanged
+	source.IsPullRequest = strings.Contains(i.Source, "/pull/")
+	return source
+}
 
-	checklists := findChecklistBlocks(content)
+// Parse returns the first chain in the content.
+func Parse(current ChainIssue, content string) (*Chain, error) {
+	chains, err := ParseAll(current, content)
+	if err != nil {
+		return nil, err
+	}
+	return chains[0], nil
+}
 
-	for _, ind := range indicators {
-		indLineNumber := ind.LineNumber
+// ParseNamed returns the chain with the given name, or the first chain if the name is empty.
+func ParseNamed(current ChainIssue, content, name string) (*Chain, error) {
+	chains, err := ParseAll(current, content)
+	if err != nil {
+		return nil, err
+	}
+	for _, chain := range chains {
+		if name == "" || strings.EqualFold(chain.Name, name) {
+			return chain, nil
+		}
+	}
+	return nil, fmt.Errorf("%w with name %s", ErrNotFound, name)
+}
 
-		c := sort.Search(len(checklists), func(i int) bool {
-			return checklists[i].LineNumbers[0] > indLineNumber
-		})
+// ParseAll returns every chain in the content in the order they appear.
+func ParseAll(current ChainIssue, content string) ([]*Chain, error) {
+	doc := parseDocument(content)
 
-		if c >= len(checklists) {
-			slog.Warn("no list found for indicator", "lineNumber", indLineNumber)
+	var chains []*Chain
+	for _, block := range doc.chains() {
+		if block.End < 0 {
+			slog.Warn("no list found for indicator", "lineNumber", block.Indicator.LineNumber)
 			continue
 		}
+		chains = append(chains, doc.chain(current, block))
+	}
+
+	if len(chains) == 0 {
+		return nil, ErrNotFound
+	}
+	return chains, nil
+}
 
-		checklistForIndicator := checklists[c]
-		return &Chain{
-			Header:  closestValidHeaderTo(content, indLineNumber).Raw,
-			Source:  current,
-			Current: current,
-			Items:   blockToItems(current, checklistForIndicator),
-			Raw:     checklistForIndicator.Raw,
-		}, nil
+// ParseMatching returns the chain in the content that the rendered chain would
+// replace, which is how it was last written.
+func ParseMatching(current ChainIssue, content, chain string) (*Chain, error) {
+	doc := parseDocument(content)
+	block, ok := doc.matchingChain(parseDocument(chain).chains()[0])
+	if !ok || block.End < 0 {
+		return nil, ErrNotFound
 	}
+	return doc.chain(current, block), nil
+}
 
+// ParseGenerated returns the chain in the content that was generated from the
+// same source as the rendered chain, and has the same name. Unlike
+// ParseMatching, chains written by hand or generated from another source are
+// not returned, as they belong to another chain.
+func ParseGenerated(current ChainIssue, content, chain string) (*Chain, error) {
+	rendered := parseIndicator(parseDocument(chain).chains()[0].Indicator.Raw)
+	source := issueFromString(rendered.Source)
+
+	doc := parseDocument(content)
+	for _, block := range doc.chains() {
+		indicator := parseIndicator(block.Indicator.Raw)
+		if block.End < 0 || indicator.Source == "" || !strings.EqualFold(indicator.Name, rendered.Name) {
+			continue
+		}
+		if issueFromString(indicator.Source).IsSame(source) {
+			return doc.chain(current, block), nil
+		}
+	}
 	return nil, ErrNotFound
 }
 
+func (d *document) chain(current ChainIssue, block chainBlock) *Chain {
+	items, _ := d.items(current, block)
+	if _, _, stored := d.storedItems(block); block.HasEnd && !stored {
+		// templates may mention an item more than once e.g. in previous and next links
+		items = uniqueItems(items)
+	}
+
+	indicator := parseIndicator(block.Indicator.Raw)
+	return &Chain{
+		Name:       indicator.Name,
+		Header:     block.Header.Raw,
+		Source:     indicator.source(current),
+		Current:    current,
+		Items:      items,
+		Raw:        d.raw(block),
+		SyncedHash: indicator.Hash,
+	}
+}
+
+// matchingChain returns the chain that a chain rendered as rendered replaces:
+// the first chain with a matching indicator that has a list, or the first
+// matching indicator if none have a list.
+func (d *document) matchingChain(rendered chainBlock) (chainBlock, bool) {
+	chainIndicator := parseIndicator(rendered.Indicator.Raw)
+	blocks := slices.DeleteFunc(d.chains(), func(c chainBlock) bool {
+		return !parseIndicator(c.Indicator.Raw).matches(chainIndicator)
+	})
+	if len(blocks) == 0 {
+		return chainBlock{}, false
+	}
+
+	if i := slices.IndexFunc(blocks, func(c chainBlock) bool { return c.End >= 0 }); i >= 0 {
+		return blocks[i], true
+	}
+	slog.Warn("no list found for indicator", "lineNumber", blocks[0].Indicator.LineNumber)
+	return blocks[0], true
+}
+
 func FindMatchGroups(re *regexp.Regexp, s string) (map[string]string, bool) {
 	getNamedMatches := func(re *regexp.Regexp, matches []string) map[string]string {
 		result := make(map[string]string)
@@ -71,45 +193,78 @@ func FindMatchGroups(re *regexp.Regexp, s string) (map[string]string, bool) {
 	return getNamedMatches(re, matches), len(matches) > 0
 }
 
+// ReplaceChain replaces the chain in the body that matches the name or source of
+// the rendered chain, or appends it if there is no match.
 func ReplaceChain(body, chain string) string {
-	indicators := findRE(body, indicatorRE)
-	if len(indicators) == 0 {
-		// not found so append the chain to the current body
-		return body + "\n" + chain
-	}
+	return ReplaceChainAt(body, chain, InsertBottom)
+}
 
-	checklists := findChecklistBlocks(body)
-	for _, ind := range indicators {
-		indLineNumber := ind.LineNumber
+// Insert is where a chain is added to a body that does not have it yet.
+type Insert string
 
-		c := sort.Search(len(checklists), func(i int) bool {
-			return checklists[i].LineNumbers[0] > indLineNumber
-		})
+const (
+	InsertBottom Insert = "bottom"
+	InsertTop    Insert = "top"
+)
 
-		if c >= len(checklists) {
-			slog.Warn("no list found for indicator", "lineNumber", indLineNumber)
-			continue
-		}
+func (i Insert) String() string {
+	return string(i)
+}
 
-		start := indLineNumber
-		// start from header if it was found in body and replacement chain
-		if closestValidHeaderTo(chain, findRE(chain, indicatorRE)[0].LineNumber).Raw != "" {
-			header := closestValidHeaderTo(body, indLineNumber)
-			if header.Raw != "" {
-				start = header.LineNumber
-			}
+// Set implements flag.Value.
+func (i *Insert) Set(value string) error {
+	switch insert := Insert(value); insert {
+	case InsertBottom, InsertTop:
+		*i = insert
+		return nil
+	}
+	return fmt.Errorf("unknown insert position %q", value)
+}
+
+// ReplaceChainAt replaces the chain in the body, or inserts it at the top or
+// bottom of the body if it is not there.
+func ReplaceChainAt(body, chain string, insert Insert) string {
+	rendered := parseDocument(chain).chains()[0]
+	target, ok := parseDocument(body).matchingChain(rendered)
+	if !ok {
+		// not found so add the chain to the current body
+		if insert == InsertTop {
+			return chain + "\n\n" + body
 		}
+		return body + "\n" + chain
+	}
 
-		// end at bottom of checklist
-		checklistForIndicator := checklists[c]
-		checklistEndLineNumber := checklistForIndicator.LineNumbers[len(checklistForIndicator.LineNumbers)-1]
+	// start from header if it was found in body and replacement chain
+	start := target.Start(rendered.Header.LineNumber >= 0)
+	end := max(target.End, target.Indicator.LineNumber)
 
-		// remove and insert new checklist
-		body = removeLines(body, start, checklistEndLineNumber)
-		return insertLinesAt(body, start, chain)
+	// remove and insert new chain
+	body = removeLines(body, start, end)
+	return insertLinesAt(body, start, chain)
+}
+
+// RemoveChain removes the chain that the rendered chain would replace from the
+// body, along with its header, leaving the rest of the body as it was. Only the
+// header, indicator and item lines are removed, so text continuing the last
+// item is kept.
+func RemoveChain(body, chain string) string {
+	target, ok := parseDocument(body).matchingChain(parseDocument(chain).chains()[0])
+	if !ok {
+		return body
 	}
 
-	return strings.ReplaceAll(body, indicators[0].Raw, chain)
+	lines := strings.Split(body, "\n")
+	start, end := target.Start(true), max(target.End, target.Indicator.LineNumber)
+	isBlank := func(i int) bool { return strings.TrimSpace(lines[i]) == "" }
+
+	// drop one of the blank lines that separated the chain from the text around it
+	switch {
+	case end+1 < len(lines) && isBlank(end+1) && (start == 0 || isBlank(start-1)):
+		end++
+	case end+1 == len(lines) && start > 0 && isBlank(start-1):
+		start--
+	}
+	return strings.Join(slices.Delete(lines, start, end+1), "\n")
 }
 
 func removeLines(s string, start, end int) string {
@@ -125,26 +280,15 @@ func insertLinesAt(s string, at int, with string) string {
 	return strings.Join(lines, "\n")
 }
 
-func blockToItems(current ChainIssue, b block) (items []ChainItem) {
-	for _, line := range strings.Split(b.Raw, "\n") {
-		matches, ok := FindMatchGroups(itemRE, line)
-		if !ok {
-			continue
+// uniqueItems returns the items without any that refer to the same issue as an earlier item.
+func uniqueItems(items []ChainItem) []ChainItem {
+	var unique []ChainItem
+	for _, item := range items {
+		if !slices.ContainsFunc(unique, func(u ChainItem) bool { return u.ChainIssue.IsSame(item.ChainIssue) }) {
+			unique = append(unique, item)
 		}
-		message := parseMessage(matches)
-		issue := issueFromMessage(current.Repo, message)
-		items = append(items,
-			ChainItem{
-				ChainIssue: issue,
-				IsCurrent:  issue == current,
-				Message:    message,
-				ItemState:  parseItemState(matches),
-				Raw:        line,
-			},
-		)
 	}
-
-	return
+	return unique
 }
 
 func parseItemState(s map[string]string) ItemState {
@@ -165,122 +309,88 @@ func parseItemState(s map[string]string) ItemState {
 
 func parseMessage(s map[string]string) string {
 	trimIndicator := func(str string) string {
-		return strings.TrimSuffix(str, CurrentIndicator)
+		// the default is also trimmed so chains survive changing the indicator
+		str = strings.TrimSuffix(strings.TrimSuffix(str, CurrentIndicator), DefaultCurrentIndicator)
+		return strings.TrimSuffix(str, AncestorIndicator)
+	}
+	trimBadge := func(str string) string {
+		return badgeRE.ReplaceAllString(str, "")
+	}
+	return strings.TrimSpace(trimBadge(strings.TrimSpace(trimIndicator(strings.TrimSpace(s["Message"])))))
+}
+
+// splitTitle splits a title rendered after the ref from the message.
+func splitTitle(message string) (string, string) {
+	ref, title, found := strings.Cut(message, TitleSeparator)
+	if !found || strings.ContainsAny(ref, " \t") || issueFromString(ref).Number == 0 {
+		return message, ""
 	}
-	return strings.TrimSpace(trimIndicator(strings.TrimSpace(s["Message"])))
+	return ref, strings.TrimSpace(title)
 }
 
 func issueFromMessage(currentRepo repository.Repository, s string) ChainIssue {
 	issue := issueFromString(s)
-	if issue.Repo == (repository.Repository{}) {
-		issue.Repo = currentRepo
-	}
+	// shorthand refs leave out the parts of the repo that are the same as the current one
+	issue.Repo.Host = iif(issue.Repo.Host == "", currentRepo.Host, issue.Repo.Host)
+	issue.Repo.Owner = iif(issue.Repo.Owner == "", currentRepo.Owner, issue.Repo.Owner)
+	issue.Repo.Name = iif(issue.Repo.Name == "", currentRepo.Name, issue.Repo.Name)
 	return issue
 }
 
+// issueFromString returns the issue referred to by the leftmost ref in s. Only
+// the parts of the repo given in the ref are set.
 func issueFromString(s string) ChainIssue {
-	urlRE := regexp.MustCompile(`(?:https?://(?P<host>[^/]+)/(?P<owner>[^/]+)/(?P<repo>[^/]+)/(issues|pull)/(?P<number>\d+).*)`)
-	numberRE := regexp.MustCompile(`(?:#(?P<number>\d+))`)
-
-	atoi := func(s string) int {
-		i, _ := strconv.Atoi(s)
-		return i
-	}
-
 	s = strings.TrimSpace(s)
 
-	if urlMatch, matched := FindMatchGroups(urlRE, s); matched {
-		return ChainIssue{
-			Repo: repository.Repository{
-				Host:  urlMatch["host"],
-				Owner: urlMatch["owner"],
-				Name:  urlMatch["repo"],
-			},
-			Number: atoi(urlMatch["number"]),
+	var issue ChainIssue
+	start := len(s)
+	// a markdown link is tried first, so it is read by its target rather than its text
+	for _, re := range []*regexp.Regexp{linkRefRE, urlRefRE, repoRefRE, numberRefRE} {
+		loc := re.FindStringIndex(s)
+		if loc == nil || loc[0] >= start {
+			continue
 		}
-	}
-
-	if numberMatch, matched := FindMatchGroups(numberRE, s); matched {
-		return ChainIssue{
-			Number: atoi(numberMatch["number"]),
+		if found := issueFromMatch(re, s[loc[0]:loc[1]]); found.Number != 0 {
+			issue, start = found, loc[0]
 		}
 	}
-
-	return ChainIssue{}
+	return issue
 }
 
-func findRE(content string, re *regexp.Regexp) []reMatch {
-	var matches []reMatch
-
-	lines := strings.Split(content, "\n")
-	for i, line := range lines {
-		if re.MatchString(line) {
-			matches = append(matches, reMatch{LineNumber: i, Raw: line})
-		}
+// issueFromMatch returns the issue referred to by a match of re.
+func issueFromMatch(re *regexp.Regexp, match string) ChainIssue {
+	atoi := func(s string) int {
+		i, _ := strconv.Atoi(s)
+		return i
 	}
 
-	sort.SliceStable(matches, func(i, j int) bool { return j > i })
-	return matches
-}
-
-type block struct {
-	Raw         string
-	LineNumbers []int
-}
-
-func findChecklistBlocks(content string) (blocks []block) {
-	matches := findRE(content, itemRE)
-
-	b := block{}
-	for _, m := range matches {
-		// block start
-		if b.Raw == "" {
-			b.Raw += m.Raw
-			b.LineNumbers = append(b.LineNumbers, m.LineNumber)
-			continue
+	groups, _ := FindMatchGroups(re, match)
+	switch re {
+	case linkRefRE:
+		return issueFromString(groups["url"])
+	case urlRefRE:
+		return ChainIssue{
+			Repo: repository.Repository{
+				Host:  groups["host"],
+				Owner: groups["owner"],
+				Name:  groups["repo"],
+			},
+			Number: atoi(groups["number"]),
 		}
-
-		// block continuing
-		last := b.LineNumbers[len(b.LineNumbers)-1]
-		if last+1 == m.LineNumber {
-			b.Raw += "\n" + m.Raw
-			b.LineNumbers = append(b.LineNumbers, m.LineNumber)
-			continue
+	case repoRefRE:
+		// words such as PR#12 or C#3 are not repos, and are not read as #12 either
+		if groups["owner"] == "" && strings.ToUpper(groups["repo"]) == groups["repo"] {
+			return ChainIssue{}
 		}
-
-		// block ended
-		blocks = append(blocks, b)
-		b = block{
-			Raw:         m.Raw,
-			LineNumbers: []int{m.LineNumber},
-		}
-	}
-	if b.Raw != "" {
-		blocks = append(blocks, b)
-	}
-
-	return blocks
-}
-
-func closestValidHeaderTo(content string, indLineNumber int) reMatch {
-	headers := findRE(content, headerRE)
-	if len(headers) == 0 {
-		return reMatch{LineNumber: -1}
-	}
-
-	h := sort.Search(len(headers), func(i int) bool {
-		return headers[i].LineNumber > indLineNumber
-	})
-	closest := headers[h-1]
-
-	lines := strings.Split(content, "\n")
-	for i := closest.LineNumber + 1; i < indLineNumber; i++ {
-		// check all lines between header and indicator are empty
-		if len(strings.TrimSpace(lines[i])) > 0 {
-			return reMatch{LineNumber: -1}
+		return ChainIssue{
+			Repo: repository.Repository{
+				Owner: groups["owner"],
+				Name:  groups["repo"],
+			},
+			Number: atoi(groups["number"]),
 		}
 	}
-	return closest
+	return ChainIssue{Number: atoi(groups["number"])}
 }
 
 // Concat returns a new slice concatenating the passed in slices.
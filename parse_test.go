@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/cli/go-gh/v2/pkg/repository"
@@ -18,6 +19,7 @@ const (
 	BulletedItems = `<!-- chainlink -->
 - #1
 - #2 &larr; you are here`
+	BadgedItems = "<!-- chainlink -->\n1. #1 🟣\n2. #2 `draft` &larr; you are here"
 )
 
 var (
@@ -31,6 +33,11 @@ var (
 	}
 )
 
+// repoIssue returns the issue with the number in the repo of TestIssue.
+func repoIssue(number int) ChainIssue {
+	return ChainIssue{Repo: TestIssue.Repo, Number: number}
+}
+
 func TestParse(t *testing.T) {
 	tests := map[string]struct {
 		current   ChainIssue
@@ -134,6 +141,38 @@ func TestParse(t *testing.T) {
 			},
 			errAssert: assert.NoError,
 		},
+		"BadgedItems": {
+			current: TestIssue,
+			content: BadgedItems,
+			want: &Chain{
+				Source:  TestIssue,
+				Current: TestIssue,
+				Items: []ChainItem{
+					{
+						ChainIssue: ChainIssue{
+							Repo:   TestIssue.Repo,
+							Number: 1,
+						},
+						IsCurrent: true,
+						Message:   "#1",
+						ItemState: Numbered,
+						Raw:       "1. #1 🟣",
+					},
+					{
+						ChainIssue: ChainIssue{
+							Repo:   TestIssue.Repo,
+							Number: 2,
+						},
+						IsCurrent: false,
+						Message:   "#2",
+						ItemState: Numbered,
+						Raw:       "2. #2 `draft` &larr; you are here",
+					},
+				},
+				Raw: "1. #1 🟣\n2. #2 `draft` &larr; you are here",
+			},
+			errAssert: assert.NoError,
+		},
 		"WithHeader": {
 			current: TestIssue,
 			content: fmt.Sprintf("### PR Chain \n%s", BulletedItems),
@@ -209,6 +248,166 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseAll(t *testing.T) {
+	content := `## Backend
+<!--chainlink name=backend-->
+1. #1
+2. #2
+
+## Rollout
+<!-- chainlink name=rollout generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->
+- [ ] #3
+- [x] #4`
+
+	chains, err := ParseAll(TestIssue, content)
+	assert.NoError(t, err)
+	if assert.Len(t, chains, 2) {
+		assert.Equal(t, "backend", chains[0].Name)
+		assert.Equal(t, "## Backend", chains[0].Header)
+		assert.Equal(t, "1. #1\n2. #2", chains[0].Raw)
+		assert.Equal(t, "rollout", chains[1].Name)
+		assert.Equal(t, "## Rollout", chains[1].Header)
+		assert.Equal(t, "- [ ] #3\n- [x] #4", chains[1].Raw)
+	}
+
+	rollout, err := ParseNamed(TestIssue, content, "rollout")
+	assert.NoError(t, err)
+	assert.Equal(t, chains[1], rollout)
+
+	_, err = ParseNamed(TestIssue, content, "frontend")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestParseFromMember(t *testing.T) {
+	member := func(number int, isPull bool) ChainIssue {
+		return ChainIssue{Repo: TestIssue.Repo, Number: number, IsPullRequest: isPull}
+	}
+	tests := map[string]ChainIssue{
+		"IssueSource": member(1, false),
+		"PullSource":  member(1, true),
+	}
+	for name, source := range tests {
+		t.Run(name, func(t *testing.T) {
+			original := NewChain([]ChainIssue{source, member(2, true), member(3, true)})
+			bodies := map[int]string{}
+			for _, number := range []int{1, 2, 3} {
+				bodies[number] = "Description\n\n" + original.ResetCurrent(member(number, true)).RenderMarkdown()
+			}
+
+			// sync again from the second item rather than the source
+			chain, err := Parse(member(2, false), bodies[2])
+			assert.NoError(t, err)
+			assert.Equal(t, source, chain.Source)
+
+			for number, body := range bodies {
+				updated := ReplaceChain(body, chain.ResetCurrent(member(number, true)).RenderMarkdown())
+				assert.Equal(t, 1, strings.Count(updated, "<!-- chainlink"), "item %d has one chain", number)
+				assert.Equal(t, body, updated, "item %d is unchanged", number)
+			}
+		})
+	}
+}
+
+func TestParseNested(t *testing.T) {
+	content := `<!--chainlink-->
+1. #1
+   1. #2
+      - [ ] #3
+      - [x] #4
+   2. #5
+2. Rollout
+   - #6`
+
+	chain, err := Parse(TestIssue, content)
+	assert.NoError(t, err)
+
+	var depths []int
+	var messages []string
+	for _, item := range chain.Items {
+		depths = append(depths, item.Depth)
+		messages = append(messages, item.Message)
+	}
+	assert.Equal(t, []int{0, 1, 2, 2, 1, 0, 1}, depths)
+	assert.Equal(t, []string{"#1", "#2", "#3", "#4", "#5", "Rollout", "#6"}, messages)
+	assert.Equal(t, "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=1e2c35ce --> \n"+
+		strings.Join(strings.Split(content, "\n")[1:], " \n"),
+		chain.ResetCurrent(ChainIssue{Repo: TestIssue.Repo}).RenderMarkdown())
+}
+
+func TestParseNestedChecklist(t *testing.T) {
+	chain := Chain{
+		Source: TestIssue,
+		Items: []ChainItem{
+			{ChainIssue: repoIssue(1), Message: "#1", ItemState: Checked},
+			{ChainIssue: repoIssue(2), Message: "#2", ItemState: Unchecked, Depth: 1},
+			{ChainIssue: repoIssue(3), Message: "#3", ItemState: Numbered, Depth: 2},
+		},
+	}
+	rendered := chain.RenderMarkdown()
+	assert.Equal(t, "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=d24ff440 --> \n- [x] #1 \n  - [ ] #2 \n    1. #3", rendered)
+
+	parsed, err := Parse(TestIssue, rendered)
+	assert.NoError(t, err)
+	var depths []int
+	for _, item := range parsed.Items {
+		depths = append(depths, item.Depth)
+	}
+	assert.Equal(t, []int{0, 1, 2}, depths)
+}
+
+func TestParseShorthandRefs(t *testing.T) {
+	content := `<!--chainlink-->
+1. #1
+2. gh-chainlink#2 — Fix parser
+3. cli/go-gh#3
+4. GH-4
+5. [Rollout](https://github.com/RoryQ/infra/pull/5)`
+
+	chain, err := Parse(TestIssue, content)
+	assert.NoError(t, err)
+
+	var refs []string
+	for _, item := range chain.Items {
+		refs = append(refs, item.Ref())
+	}
+	assert.Equal(t, []string{"RoryQ/gh-chainlink#1", "RoryQ/gh-chainlink#2", "cli/go-gh#3", "RoryQ/gh-chainlink#4", "RoryQ/infra#5"}, refs)
+	assert.Equal(t, "Fix parser", chain.Items[1].Title)
+}
+
+func Test_splitTitle(t *testing.T) {
+	tests := map[string]struct {
+		message   string
+		wantRef   string
+		wantTitle string
+	}{
+		"NoTitle": {
+			message: "#101",
+			wantRef: "#101",
+		},
+		"Title": {
+			message:   "#101 — Add parser for v2 config",
+			wantRef:   "#101",
+			wantTitle: "Add parser for v2 config",
+		},
+		"URLTitle": {
+			message:   "https://github.com/owner/repo/pull/7 — Fix — again",
+			wantRef:   "https://github.com/owner/repo/pull/7",
+			wantTitle: "Fix — again",
+		},
+		"NotARef": {
+			message: "Rollout — phase one",
+			wantRef: "Rollout — phase one",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref, title := splitTitle(tt.message)
+			assert.Equal(t, tt.wantRef, ref)
+			assert.Equal(t, tt.wantTitle, title)
+		})
+	}
+}
+
 func Test_issueFromMessage(t *testing.T) {
 	tests := map[string]struct {
 		current ChainIssue
@@ -247,6 +446,81 @@ func Test_issueFromMessage(t *testing.T) {
 				Number: 123,
 			},
 		},
+		"OwnerRepoShorthand": {
+			current: TestIssue,
+			message: "cli/go-gh#42",
+			want:    ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "cli", Name: "go-gh"}, Number: 42},
+		},
+		"RepoShorthand": {
+			current: TestIssue,
+			message: "other.repo#42",
+			want:    ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "RoryQ", Name: "other.repo"}, Number: 42},
+		},
+		"ShortRepoShorthand": {
+			current: TestIssue,
+			message: "b#4",
+			want:    ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "RoryQ", Name: "b"}, Number: 4},
+		},
+		"WordBeforeNumber": {
+			current: TestIssue,
+			message: "PR#12",
+			want:    ChainIssue{Repo: TestIssue.Repo},
+		},
+		"LanguageBeforeNumber": {
+			current: TestIssue,
+			message: "Port to C#3",
+			want:    ChainIssue{Repo: TestIssue.Repo},
+		},
+		"UppercaseRepoWithOwner": {
+			current: TestIssue,
+			message: "RoryQ/API#3",
+			want:    ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "RoryQ", Name: "API"}, Number: 3},
+		},
+		"NumberInParentheses": {
+			current: TestIssue,
+			message: "Fix parser (#12)",
+			want:    repoIssue(12),
+		},
+		"LeftmostRef": {
+			current: TestIssue,
+			message: "#7 follows cli/go-gh#42",
+			want:    repoIssue(7),
+		},
+		"LeftmostRefBeforeURL": {
+			current: TestIssue,
+			message: "cli/go-gh#42, see https://github.com/owner/repo/issues/123",
+			want:    ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "cli", Name: "go-gh"}, Number: 42},
+		},
+		"ShorthandInText": {
+			current: TestIssue,
+			message: "Follow up to cli/go-gh#42",
+			want:    ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "cli", Name: "go-gh"}, Number: 42},
+		},
+		"GHShorthand": {
+			current: TestIssue,
+			message: "GH-123",
+			want:    repoIssue(123),
+		},
+		"MarkdownLink": {
+			current: TestIssue,
+			message: "[Add parser](https://github.com/owner/repo/pull/123)",
+			want:    ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "owner", Name: "repo"}, Number: 123},
+		},
+		"MarkdownLinkWithoutRefTarget": {
+			current: TestIssue,
+			message: "[docs](https://example.com/docs) for #5",
+			want:    repoIssue(5),
+		},
+		"MarkdownLinkPrefersURL": {
+			current: TestIssue,
+			message: "[#4](https://github.com/owner/repo/issues/123)",
+			want:    ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "owner", Name: "repo"}, Number: 123},
+		},
+		"NoRef": {
+			current: TestIssue,
+			message: "Rollout",
+			want:    ChainIssue{Repo: TestIssue.Repo},
+		},
 	}
 	for name, tt := range tests {
 		t.Run(name, func(t *testing.T) {
@@ -286,6 +560,21 @@ func TestReplaceChain(t *testing.T) {
 			chain: "### PR Chain\n<!--chainlink-->\n1. #1 &larr; you are here",
 			want:  "### PR Chain\n<!--chainlink-->\n1. #1 &larr; you are here",
 		},
+		"NamedChainReplacesSameName": {
+			body:  "<!--chainlink name=backend-->\n1. #1\n\n<!--chainlink name=rollout-->\n1. #3",
+			chain: "<!-- chainlink name=rollout generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #3 &larr; you are here",
+			want:  "<!--chainlink name=backend-->\n1. #1\n\n<!-- chainlink name=rollout generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #3 &larr; you are here",
+		},
+		"NamedChainDoesNotReplaceUnnamed": {
+			body:  "<!--chainlink-->\n1. #1",
+			chain: "<!-- chainlink name=rollout generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #3 &larr; you are here",
+			want:  "<!--chainlink-->\n1. #1\n<!-- chainlink name=rollout generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #3 &larr; you are here",
+		},
+		"UnnamedChainReplacesSameSource": {
+			body:  "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/9 -->\n1. #9\n\n<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #1",
+			chain: "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #1 &larr; you are here",
+			want:  "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/9 -->\n1. #9\n\n<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #1 &larr; you are here",
+		},
 		"BodyHasChainlinkAndHeaderButChainDoesNot": {
 			body:  "### PR Chain\n<!--chainlink-->\n\n1. #1",
 			chain: "<!--chainlink-->\n1. #1 &larr; you are here",
@@ -298,3 +587,11 @@ func TestReplaceChain(t *testing.T) {
 		})
 	}
 }
+
+func TestReplaceChainAt(t *testing.T) {
+	chain := "<!--chainlink-->\n1. #1 &larr; you are here"
+	assert.Equal(t, chain+"\n\nSome Text.", ReplaceChainAt("Some Text.", chain, InsertTop))
+	assert.Equal(t, "Some Text.\n"+chain, ReplaceChainAt("Some Text.", chain, InsertBottom))
+	// an existing chain stays where it is
+	assert.Equal(t, "Some Text.\n"+chain, ReplaceChainAt("Some Text.\n<!--chainlink-->\n1. #1", chain, InsertTop))
+}
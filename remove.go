@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+func removeCommand(client *GhClient, args []string) error {
+	fs := flag.NewFlagSet("remove", flag.ExitOnError)
+	name := fs.String("chain", "", "Name of the chain to remove from when the body has more than one.")
+	opts := addSyncFlags(fs)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: gh chainlink remove [flags] <chain ref> <item ref>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("remove needs a chain ref and an item ref")
+	}
+
+	chainRef := getTargetIssue(fs.Args()[:1])
+	if chainRef.Number == 0 {
+		return ErrNoIssueRef
+	}
+	if err := configure(client, fs, opts, chainRef.Repo); err != nil {
+		return err
+	}
+
+	chain, err := loadChain(client, fs.Args()[:1], *name)
+	if err != nil {
+		return err
+	}
+
+	issue := resolveRef(chain.Source.Repo, fs.Arg(1))
+	index := chain.Index(issue)
+	if issue.Number == 0 || index < 0 {
+		return fmt.Errorf("%w: %s", ErrNotInChain, fs.Arg(1))
+	}
+
+	// the sync finds the removed item in the chain the source was last written
+	// with, and removes the chain from its body
+	opts.UpdateSource = true
+	return runSync(client, chain.RemoveItem(index), *opts)
+}
+
+// removedItems returns the items that are in the chains last written to the
+// source and the items, but are no longer in the chain.
+func removedItems(client *GhClient, chain Chain, fetched prefetched) []ChainItem {
+	indicator := chain.renderIndicator()
+	var removed []ChainItem
+	for _, member := range members(chain) {
+		issue, err := fetched.get(client, member)
+		if err != nil {
+			// the sync reports the items that cannot be retrieved
+			slog.Debug("error retrieving item", "number", member.Number, "error", err)
+			continue
+		}
+
+		// the source may have the chain written by hand, but the chains in other
+		// items are only this chain if they were generated from the same source
+		parse := iif(member.IsSame(chain.Source), ParseMatching, ParseGenerated)
+		previous, err := parse(member, issue.Body, indicator)
+		if err != nil {
+			continue
+		}
+		for _, item := range previous.Items {
+			isRemoved := func(r ChainItem) bool { return r.IsSame(item.ChainIssue) }
+			if item.Number != 0 && chain.Index(item.ChainIssue) < 0 && !slices.ContainsFunc(removed, isRemoved) {
+				removed = append(removed, item)
+			}
+		}
+	}
+	return removed
+}
+
+// removeChains removes the chain from the body of each removed item, printing
+// the result of each one and returning how many had each result.
+func removeChains(w io.Writer, client *GhClient, chain Chain, removed []ChainItem, opts syncOptions) (map[string]int, error) {
+	var err error
+	results := map[string]int{}
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for i, item := range removed {
+		start := time.Now()
+		response := responseMsg{index: i}
+		response.result, response.diff, response.err = removeChain(client, chain, item, opts)
+		response.duration = time.Since(start)
+		results[response.result]++
+		if response.err != nil {
+			err = ErrItemsFailed
+		}
+
+		if !opts.JSON {
+			renderResponse(w, "-", item, response)
+			continue
+		}
+		// removed items are no longer in the chain, so they have no index
+		record := itemRecord{
+			Type:       "removed",
+			Ref:        item.Ref(),
+			URL:        item.URL(),
+			Result:     response.result,
+			Diff:       response.diff,
+			DurationMs: response.duration.Milliseconds(),
+		}
+		if response.err != nil {
+			record.Error = response.err.Error()
+		}
+		if encErr := encoder.Encode(record); encErr != nil {
+			return nil, encErr
+		}
+	}
+	return results, err
+}
+
+func removeChain(client *GhClient, chain Chain, item ChainItem, opts syncOptions) (string, string, error) {
+	itemIssue, err := client.GetIssue(item.Key())
+	if err != nil {
+		return "error", "", fmt.Errorf("error retrieving item %d: %w", item.Number, err)
+	}
+	item.IsPullRequest = itemIssue.IsPull()
+
+	// the chain is found by its indicator, so the items are not rendered
+	indicator := chain.renderIndicator()
+	result, diff, err := editBody(client, item, itemIssue, opts, func(body string) string {
+		return RemoveChain(body, indicator)
+	})
+	return iif(result == "updated", "removed", result), diff, err
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveChain(t *testing.T) {
+	chain := "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #1\n2. #2 &larr; you are here"
+	tests := map[string]struct {
+		body string
+		want string
+	}{
+		"Bottom": {
+			body: "Description\n" + chain,
+			want: "Description",
+		},
+		"BottomAfterBlankLine": {
+			body: "Description\n\n" + chain,
+			want: "Description",
+		},
+		"Top": {
+			body: chain + "\n\nDescription",
+			want: "Description",
+		},
+		"Middle": {
+			body: "Before\n\n## PR Chain\n" + chain + "\n\nAfter\n",
+			want: "Before\n\nAfter\n",
+		},
+		"Templated": {
+			body: "Before\n\n<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n| #1 | #2 |\n<!-- /chainlink -->\n\nAfter",
+			want: "Before\n\nAfter",
+		},
+		"OtherChainIsKept": {
+			body: "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/9 -->\n1. #9",
+			want: "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/9 -->\n1. #9",
+		},
+		"TextRightAfterList": {
+			body: "Intro\n\n<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 -->\n1. #1\n2. #2\nThanks for reviewing!",
+			want: "Intro\n\nThanks for reviewing!",
+		},
+		"HandWrittenWithTextRightAfterList": {
+			body: "Intro\n\n## PR Chain\n<!--chainlink-->\n1. #1\n2. #2\nThanks for reviewing!\n",
+			want: "Intro\n\nThanks for reviewing!\n",
+		},
+		"NoChain": {
+			body: "Description",
+			want: "Description",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RemoveChain(tt.body, chain))
+		})
+	}
+}
+
+func TestChain_RemoveItem(t *testing.T) {
+	chain, err := Parse(TestIssue, "<!--chainlink-->\n1. #1\n2. #2\n   1. #3\n      - #4\n3. #5")
+	assert.NoError(t, err)
+
+	expected := `<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=b53131d1 --> 
+1. #1 &larr; you are here 
+2. #3 
+   - #4 
+3. #5`
+	assert.Equal(t, expected, chain.RemoveItem(1).ResetCurrent(TestIssue).RenderMarkdown())
+}
+
+func TestRemovedItems(t *testing.T) {
+
+	tests := map[string]struct {
+		name  string
+		other string
+		want  []int
+	}{
+		"RemovedItem": {
+			want: []int{3},
+		},
+		"ChainFromOtherSource": {
+			other: "<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/9 -->\n1. #4\n2. #5",
+			want:  []int{3},
+		},
+		"SameNameFromOtherSource": {
+			name:  "backend",
+			other: "<!-- chainlink name=backend generated from https://github.com/RoryQ/gh-chainlink/issues/9 -->\n1. #4\n2. #6",
+			want:  []int{3},
+		},
+		"HandWrittenChainInItem": {
+			other: "<!--chainlink-->\n- #4\n- #7",
+			want:  []int{3},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			old := NewChain([]ChainIssue{repoIssue(1), repoIssue(2), repoIssue(3), repoIssue(4)})
+			old.Name = tt.name
+			chain := old.RemoveItem(2)
+
+			fetched := prefetched{
+				repoIssue(1): {Body: chain.ResetCurrent(repoIssue(1)).RenderMarkdown()},
+				repoIssue(2): {Body: "Description\n" + old.ResetCurrent(repoIssue(2)).RenderMarkdown()},
+				// #4 was added to this chain after it was added to another
+				repoIssue(4): {Body: tt.other},
+			}
+
+			var removed []int
+			for _, item := range removedItems(nil, chain, fetched) {
+				removed = append(removed, item.Number)
+			}
+			assert.Equal(t, tt.want, removed)
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/cli/go-gh/v2/pkg/tableprinter"
+	"github.com/cli/go-gh/v2/pkg/term"
+	"github.com/fatih/color"
+)
+
+var ErrNotConfirmed = errors.New("not confirmed")
+
+type retarget struct {
+	Item    ChainItem
+	Head    string
+	OldBase string
+	NewBase string
+}
+
+func retargetCommand(client *GhClient, args []string) error {
+	fs := flag.NewFlagSet("retarget", flag.ExitOnError)
+	yes := fs.Bool("yes", false, "Retarget without asking for confirmation.")
+	dryRun := fs.Bool("dry-run", false, "Print the changes without retargeting.")
+	name := fs.String("chain", "", "Name of the chain to use when the body has more than one.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	chain, err := loadChain(client, fs.Args(), *name)
+	if err != nil {
+		return err
+	}
+
+	return retargetChain(client, *chain, *yes, *dryRun)
+}
+
+// retargetChain sets the base of each open pull request in the chain to the
+// head of the pull request it is stacked on, after printing the changes and asking for confirmation.
+func retargetChain(client *GhClient, chain Chain, yes, dryRun bool) error {
+	pulls, err := fetchPulls(client, chain.Items)
+	if err != nil {
+		return err
+	}
+
+	changes := planRetarget(chain.Items, pulls)
+	if len(changes) == 0 {
+		_, _ = fmt.Fprintln(color.Output, "All pull request bases already match the chain order.")
+		return nil
+	}
+
+	if err := renderRetargetTable(os.Stdout, changes); err != nil {
+		return err
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	if !yes {
+		if err := confirm(fmt.Sprintf("Retarget %d pull requests?", len(changes))); err != nil {
+			return err
+		}
+	}
+
+	for _, change := range changes {
+		if err := client.UpdatePullBase(change.Item.ChainIssue, change.NewBase); err != nil {
+			return fmt.Errorf("error retargeting %d: %w", change.Item.Number, err)
+		}
+		_, _ = fmt.Fprintln(color.Output, green("✓"), change.Item.Message, hiBlack(change.OldBase), "→", change.NewBase)
+	}
+	return nil
+}
+
+// fetchPulls returns the pull request for each item, or nil if the item is an
+// issue or a group heading.
+func fetchPulls(client *GhClient, items []ChainItem) ([]*PullResponse, error) {
+	pulls := make([]*PullResponse, len(items))
+	for i, item := range items {
+		if item.Number == 0 {
+			continue
+		}
+		pull, err := client.GetPull(item.ChainIssue)
+		he := &api.HTTPError{}
+		if errors.As(err, &he) && he.StatusCode == http.StatusNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving item %d: %w", item.Number, err)
+		}
+		pulls[i] = &pull
+	}
+	return pulls, nil
+}
+
+// planRetarget returns the open pull requests whose base is not the head of
+// the pull request they are stacked on in the same repo.
+func planRetarget(items []ChainItem, pulls []*PullResponse) []retarget {
+	var changes []retarget
+	for i, next := range pulls {
+		if !isOpen(next) {
+			continue
+		}
+		base := stackBase(items, pulls, i)
+		if base < 0 || items[base].Repo != items[i].Repo {
+			continue
+		}
+		prev := pulls[base]
+		if next.Base.Ref == prev.Head.Ref {
+			continue
+		}
+		changes = append(changes, retarget{
+			Item:    items[i],
+			Head:    next.Head.Ref,
+			OldBase: next.Base.Ref,
+			NewBase: prev.Head.Ref,
+		})
+	}
+	return changes
+}
+
+// stackBase returns the index of the open pull request the item at index i is
+// stacked on, or -1 if there is none. An item is stacked on the sibling before
+// it, or on its parent when it is the first child. Pull requests that are
+// merged or closed are passed over for the one they were stacked on, while
+// issues and group headings end the stack.
+func stackBase(items []ChainItem, pulls []*PullResponse, i int) int {
+	for {
+		i = stackedOn(items, i)
+		if i < 0 || pulls[i] == nil {
+			return -1
+		}
+		if isOpen(pulls[i]) {
+			return i
+		}
+	}
+}
+
+// stackedOn returns the index of the sibling before the item at index i, or
+// its parent when it is the first child, or -1 for the first top level item.
+func stackedOn(items []ChainItem, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if items[j].Depth <= items[i].Depth {
+			return j
+		}
+	}
+	return -1
+}
+
+func isOpen(pull *PullResponse) bool {
+	return pull != nil && pull.State == "open"
+}
+
+func renderRetargetTable(w io.Writer, changes []retarget) error {
+	t := term.FromEnv()
+	width, _, _ := t.Size()
+	tp := tableprinter.New(w, t.IsTerminalOutput(), width)
+	tp.AddHeader([]string{"PULL REQUEST", "HEAD", "BASE BEFORE", "BASE AFTER"})
+	for _, change := range changes {
+		tp.AddField(change.Item.Message)
+		tp.AddField(change.Head)
+		tp.AddField(change.OldBase, tableprinter.WithColor(stringFunc(red)))
+		tp.AddField(change.NewBase, tableprinter.WithColor(stringFunc(green)))
+		tp.EndRow()
+	}
+	return tp.Render()
+}
+
+// confirm asks a yes/no question on the terminal and returns ErrNotConfirmed unless answered yes.
+func confirm(question string) error {
+	t := term.FromEnv()
+	if !t.IsTerminalOutput() {
+		return fmt.Errorf("%w: pass --yes to confirm when not running in a terminal", ErrNotConfirmed)
+	}
+
+	_, _ = fmt.Fprintf(t.Out(), "%s [y/N] ", question)
+	answer, err := bufio.NewReader(t.In()).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return ErrNotConfirmed
+	}
+	return nil
+}
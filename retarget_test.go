@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cli/go-gh/v2/pkg/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanRetarget(t *testing.T) {
+	otherRepo := ChainIssue{Repo: repository.Repository{Host: "github.com", Owner: "RoryQ", Name: "other"}, Number: 5}
+	item := func(number, depth int) ChainItem {
+		return ChainItem{ChainIssue: repoIssue(number), Message: fmt.Sprint("#", number), Depth: depth}
+	}
+	group := func(depth int) ChainItem {
+		return ChainItem{Message: "Rollout", Depth: depth}
+	}
+	pull := func(head, base string) *PullResponse {
+		return &PullResponse{State: "open", Head: BranchRef{Ref: head}, Base: BranchRef{Ref: base}}
+	}
+	closed := func(head, base string) *PullResponse {
+		return &PullResponse{State: "closed", Head: BranchRef{Ref: head}, Base: BranchRef{Ref: base}}
+	}
+
+	tests := map[string]struct {
+		items    []ChainItem
+		pulls    []*PullResponse
+		expected []retarget
+	}{
+		"Flat": {
+			items: []ChainItem{item(1, 0), item(2, 0), item(3, 0), item(4, 0), {ChainIssue: otherRepo, Message: otherRepo.URL()}},
+			pulls: []*PullResponse{
+				pull("feature-1", "main"),
+				pull("feature-2", "feature-1"), // already correct
+				pull("feature-3", "main"),      // should be based on feature-2
+				nil,                            // issue
+				pull("feature-5", "main"),      // different repo
+			},
+			expected: []retarget{
+				{Item: item(3, 0), Head: "feature-3", OldBase: "main", NewBase: "feature-2"},
+			},
+		},
+		"MergedPullIsPassedOver": {
+			items: []ChainItem{item(1, 0), item(2, 0), item(3, 0)},
+			pulls: []*PullResponse{
+				pull("feature-1", "main"),
+				closed("feature-2", "feature-1"),
+				pull("feature-3", "feature-2"),
+			},
+			expected: []retarget{
+				{Item: item(3, 0), Head: "feature-3", OldBase: "feature-2", NewBase: "feature-1"},
+			},
+		},
+		"ClosedPullIsNotRetargeted": {
+			items: []ChainItem{item(1, 0), item(2, 0)},
+			pulls: []*PullResponse{
+				pull("feature-1", "main"),
+				closed("feature-2", "main"),
+			},
+		},
+		"NoOpenPullBelow": {
+			items: []ChainItem{item(1, 0), item(2, 0)},
+			pulls: []*PullResponse{
+				closed("feature-1", "main"),
+				pull("feature-2", "feature-1"),
+			},
+		},
+		"Nested": {
+			items: []ChainItem{item(1, 0), item(2, 1), item(3, 1), item(4, 0), group(0), item(5, 1)},
+			pulls: []*PullResponse{
+				pull("feature-1", "main"),
+				pull("feature-2", "main"),      // first child, should be based on feature-1
+				pull("feature-3", "feature-2"), // already correct
+				pull("feature-4", "feature-3"), // should be based on its sibling feature-1
+				nil,                            // group heading
+				pull("feature-5", "feature-4"), // first child of a group heading
+			},
+			expected: []retarget{
+				{Item: item(2, 1), Head: "feature-2", OldBase: "main", NewBase: "feature-1"},
+				{Item: item(4, 0), Head: "feature-4", OldBase: "feature-3", NewBase: "feature-1"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, planRetarget(tt.items, tt.pulls))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+)
+
+const (
+	maxRetries     = 5
+	initialBackoff = 2 * time.Second
+	maxBackoff     = time.Minute
+	// defaultMaxWait is the longest wait for a rate limit to reset, which covers
+	// the hourly primary rate limit.
+	defaultMaxWait = time.Hour
+	// secondaryRateLimitWait is the minimum wait GitHub recommends after hitting a
+	// secondary rate limit without a Retry-After header.
+	secondaryRateLimitWait = time.Minute
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+// withRetry calls fn until it succeeds or fails with an error that is not a rate
+// limit or server error, waiting between attempts. Waits are reported to OnWait.
+// Waits longer than MaxWait fail with ErrRateLimited instead.
+func (c *GhClient) withRetry(fn func() error) error {
+	backoff := initialBackoff
+	for attempt := 0; ; attempt++ {
+		err := fn()
+		now := time.Now()
+		wait, retryable := retryDelay(err, backoff, now)
+		if !retryable || attempt == maxRetries {
+			return err
+		}
+		// waiting keeps the items from being left half updated, up to the limit
+		if wait > iif(c.MaxWait > 0, c.MaxWait, defaultMaxWait) {
+			return fmt.Errorf("%w until %s: %w", ErrRateLimited, now.Add(wait).Format(time.TimeOnly), err)
+		}
+
+		if c.OnWait != nil {
+			c.OnWait(wait, err)
+		} else {
+			slog.Warn("waiting for rate limit", "wait", wait.Round(time.Second), "error", err)
+		}
+		time.Sleep(wait)
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
+
+// retryDelay returns how long to wait before retrying the request that caused
+// err, and whether it should be retried at all.
+func retryDelay(err error, backoff time.Duration, now time.Time) (time.Duration, bool) {
+	he := &api.HTTPError{}
+	if !errors.As(err, &he) {
+		return 0, false
+	}
+
+	if retryAfter := he.Headers.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	isRateLimit := he.StatusCode == http.StatusForbidden || he.StatusCode == http.StatusTooManyRequests
+	if isRateLimit && he.Headers.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(he.Headers.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Unix(reset, 0).Sub(now), 0) + time.Second, true
+		}
+	}
+
+	switch {
+	case isRateLimit && strings.Contains(strings.ToLower(he.Message), "secondary rate limit"):
+		return max(backoff, secondaryRateLimitWait), true
+	case he.StatusCode == http.StatusTooManyRequests, he.StatusCode >= http.StatusInternalServerError:
+		return backoff, true
+	}
+	return 0, false
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/cli/go-gh/v2/pkg/api"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	httpError := func(status int, message string, headers map[string]string) error {
+		he := &api.HTTPError{StatusCode: status, Message: message, Headers: http.Header{}}
+		for k, v := range headers {
+			he.Headers.Set(k, v)
+		}
+		return he
+	}
+
+	tests := map[string]struct {
+		err           error
+		wantWait      time.Duration
+		wantRetryable bool
+	}{
+		"NotHTTPError": {
+			err: errors.New("boom"),
+		},
+		"NotFound": {
+			err: httpError(http.StatusNotFound, "Not Found", nil),
+		},
+		"RetryAfter": {
+			err:           httpError(http.StatusForbidden, "slow down", map[string]string{"Retry-After": "30"}),
+			wantWait:      30 * time.Second,
+			wantRetryable: true,
+		},
+		"RateLimitReset": {
+			err: httpError(http.StatusForbidden, "API rate limit exceeded", map[string]string{
+				"X-RateLimit-Remaining": "0",
+				"X-RateLimit-Reset":     strconv.FormatInt(now.Add(90*time.Second).Unix(), 10),
+			}),
+			wantWait:      91 * time.Second,
+			wantRetryable: true,
+		},
+		"SecondaryRateLimit": {
+			err:           httpError(http.StatusForbidden, "You have exceeded a secondary rate limit", nil),
+			wantWait:      secondaryRateLimitWait,
+			wantRetryable: true,
+		},
+		"ForbiddenNotRateLimited": {
+			err: httpError(http.StatusForbidden, "Resource not accessible by integration", nil),
+		},
+		"BadGateway": {
+			err:           httpError(http.StatusBadGateway, "", nil),
+			wantWait:      initialBackoff,
+			wantRetryable: true,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			wait, retryable := retryDelay(tt.err, initialBackoff, now)
+			assert.Equal(t, tt.wantRetryable, retryable)
+			assert.Equal(t, tt.wantWait, wait)
+		})
+	}
+}
+
+func TestWithRetryLongWait(t *testing.T) {
+	rateLimited := func(reset time.Duration) error {
+		he := &api.HTTPError{StatusCode: http.StatusForbidden, Message: "API rate limit exceeded", Headers: http.Header{}}
+		he.Headers.Set("X-RateLimit-Remaining", "0")
+		he.Headers.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(reset).Unix(), 10))
+		return he
+	}
+
+	t.Run("LongerThanDefault", func(t *testing.T) {
+		attempts := 0
+		err := (&GhClient{}).withRetry(func() error {
+			attempts++
+			return rateLimited(2 * time.Hour)
+		})
+		assert.ErrorIs(t, err, ErrRateLimited)
+		assert.ErrorContains(t, err, "API rate limit exceeded")
+		assert.Equal(t, 1, attempts)
+	})
+
+	t.Run("WaitsUpToMaxWait", func(t *testing.T) {
+		var waits []time.Duration
+		client := &GhClient{MaxWait: 10 * time.Minute, OnWait: func(wait time.Duration, err error) { waits = append(waits, wait) }}
+		attempts := 0
+		err := client.withRetry(func() error {
+			attempts++
+			if attempts == 1 {
+				// resets in the past, so the wait is only the extra second
+				return rateLimited(-time.Minute)
+			}
+			return rateLimited(time.Hour)
+		})
+		assert.ErrorIs(t, err, ErrRateLimited)
+		assert.Equal(t, 2, attempts)
+		assert.Equal(t, []time.Duration{time.Second}, waits)
+	})
+}
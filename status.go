@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cli/go-gh/v2/pkg/tableprinter"
+	"github.com/cli/go-gh/v2/pkg/term"
+)
+
+// statusRow is the live status of an item in the chain.
+type statusRow struct {
+	Point          string `json:"-"`
+	Message        string `json:"-"`
+	Ref            string `json:"ref"`
+	URL            string `json:"url"`
+	Type           string `json:"type"`
+	Title          string `json:"title"`
+	State          string `json:"state"`
+	Draft          bool   `json:"draft"`
+	ReviewDecision string `json:"reviewDecision,omitempty"`
+	Checks         string `json:"checks,omitempty"`
+	Base           string `json:"base,omitempty"`
+	Head           string `json:"head,omitempty"`
+	InSync         bool   `json:"inSync"`
+}
+
+func statusCommand(client *GhClient, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	name := fs.String("chain", "", "Name of the chain to show when the body has more than one.")
+	jsonOutput := fs.Bool("json", false, "Print one JSON record per item instead of a table.")
+	opts := addRenderFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	targetIssue := getTargetIssue(fs.Args())
+	if targetIssue.Number == 0 {
+		return ErrNoIssueRef
+	}
+	if err := configure(client, fs, opts, targetIssue.Repo); err != nil {
+		return err
+	}
+
+	issue, err := client.GetIssue(targetIssue)
+	if err != nil {
+		return err
+	}
+	chain, err := ParseNamed(targetIssue, issue.Body, *name)
+	if err != nil {
+		return err
+	}
+	prepared, fetched, err := prepareChain(client, *chain, *opts)
+	if err != nil {
+		return err
+	}
+
+	rows, err := statusRows(client, prepared, fetched, *opts)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		return renderStatusJSON(os.Stdout, rows)
+	}
+	return renderStatusTable(os.Stdout, prepared.Header, rows)
+}
+
+// statusRows returns the status of each item, including whether the chain in
+// its body is the one a sync would write.
+func statusRows(client *GhClient, chain Chain, fetched prefetched, opts syncOptions) ([]statusRow, error) {
+	rows := make([]statusRow, len(chain.Items))
+	for i, item := range chain.Items {
+		row := &rows[i]
+		row.Point, row.Message = chain.ListPoint(i), item.Message
+		// group headings in nested chains have nothing to show
+		if item.Number == 0 {
+			row.Type = "group"
+			continue
+		}
+
+		issue, err := fetched.get(client, item.ChainIssue)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving item %d: %w", item.Number, err)
+		}
+		item.IsPullRequest = issue.IsPull()
+		state := issue.IssueState()
+
+		row.Ref, row.URL = item.Ref(), item.URL()
+		row.Type = iif(issue.IsPull(), "pull request", "issue")
+		row.Title = issue.Title
+		row.State = string(iif(state == StateDraft, StateOpen, state))
+		row.Draft = state == StateDraft
+		row.ReviewDecision = strings.ReplaceAll(issue.ReviewDecision, "_", " ")
+		row.Checks = issue.Checks
+		row.Base, row.Head = issue.Base, issue.Head
+
+		rendered := chain.ResetCurrent(item.ChainIssue).RenderMarkdown()
+		row.InSync = ReplaceChainAt(issue.Body, rendered, opts.Insert) == issue.Body
+	}
+	return rows, nil
+}
+
+func renderStatusTable(w io.Writer, header string, rows []statusRow) error {
+	if header != "" {
+		_, _ = fmt.Fprintln(w, blue(header))
+	}
+
+	t := term.FromEnv()
+	width, _, _ := t.Size()
+	tp := tableprinter.New(w, t.IsTerminalOutput(), width)
+	tp.AddHeader([]string{"ITEM", "TYPE", "TITLE", "STATE", "DRAFT", "REVIEW", "CHECKS", "BRANCH", "SYNC"})
+	for _, row := range rows {
+		tp.AddField(row.Point + " " + row.Message)
+		tp.AddField(row.Type)
+		tp.AddField(row.Title)
+		tp.AddField(row.State, tableprinter.WithColor(stringFunc(stateColor(row.State))))
+		tp.AddField(iif(row.Draft, "draft", ""))
+		tp.AddField(row.ReviewDecision)
+		tp.AddField(row.Checks, tableprinter.WithColor(stringFunc(checksColor(row.Checks))))
+		tp.AddField(iif(row.Base != "", row.Base+" ← "+row.Head, ""))
+		switch {
+		case row.Type == "group":
+			tp.AddField("")
+		case row.InSync:
+			tp.AddField("✓ in sync", tableprinter.WithColor(stringFunc(green)))
+		default:
+			tp.AddField("✗ out of sync", tableprinter.WithColor(stringFunc(red)))
+		}
+		tp.EndRow()
+	}
+	return tp.Render()
+}
+
+func renderStatusJSON(w io.Writer, rows []statusRow) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	for _, row := range rows {
+		if row.Type == "group" {
+			continue
+		}
+		if err := encoder.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stateColor(state string) func(a ...any) string {
+	switch IssueState(state) {
+	case StateOpen:
+		return green
+	case StateMerged:
+		return blue
+	case StateClosed:
+		return red
+	}
+	return fmt.Sprint
+}
+
+func checksColor(checks string) func(a ...any) string {
+	switch checks {
+	case "success":
+		return green
+	case "pending", "expected":
+		return yellow
+	case "failure", "error":
+		return red
+	}
+	return fmt.Sprint
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusRows(t *testing.T) {
+	chain := NewChain([]ChainIssue{repoIssue(1), repoIssue(2), repoIssue(3)})
+	chain.Items = append(chain.Items[:2], ChainItem{Message: "Rollout", ItemState: Numbered}, chain.Items[2])
+	chain.Items[3].Depth = 1
+
+	pull := func(number int, body string) IssueResponse {
+		return IssueResponse{Number: number, Title: "Title", State: "open", Body: body, PullRequest: &pullRequestRef{}}
+	}
+	inSync := chain.ResetCurrent(repoIssue(1)).RenderMarkdown()
+	fetched := prefetched{
+		repoIssue(1): {Number: 1, Title: "Tracking", State: "open", Body: "Description\n" + inSync},
+		repoIssue(2): func() IssueResponse {
+			r := pull(2, "Description")
+			r.Draft, r.ReviewDecision, r.Checks, r.Base, r.Head = true, "review_required", "pending", "main", "feature-2"
+			return r
+		}(),
+		repoIssue(3): pull(3, chain.ResetCurrent(repoIssue(3)).RenderMarkdown()),
+	}
+
+	rows, err := statusRows(nil, chain, fetched, syncOptions{Insert: InsertBottom})
+	assert.NoError(t, err)
+	assert.Equal(t, []statusRow{
+		{Point: "1.", Message: "#1", Ref: "RoryQ/gh-chainlink#1", URL: "https://github.com/RoryQ/gh-chainlink/issues/1", Type: "issue", Title: "Tracking", State: "open", InSync: true},
+		{Point: "2.", Message: "#2", Ref: "RoryQ/gh-chainlink#2", URL: "https://github.com/RoryQ/gh-chainlink/pull/2", Type: "pull request", Title: "Title", State: "open", Draft: true, ReviewDecision: "review required", Checks: "pending", Base: "main", Head: "feature-2"},
+		{Point: "3.", Message: "Rollout", Type: "group"},
+		{Point: "   1.", Message: "#3", Ref: "RoryQ/gh-chainlink#3", URL: "https://github.com/RoryQ/gh-chainlink/pull/3", Type: "pull request", Title: "Title", State: "open", InSync: true},
+	}, rows)
+
+	t.Run("JSON", func(t *testing.T) {
+		buf := new(bytes.Buffer)
+		assert.NoError(t, renderStatusJSON(buf, rows[2:]))
+		assert.Equal(t, `{"ref":"RoryQ/gh-chainlink#3","url":"https://github.com/RoryQ/gh-chainlink/pull/3","type":"pull request","title":"Title","state":"open","draft":false,"inSync":true}`+"\n", buf.String())
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/cli/go-gh/v2/pkg/config"
+)
+
+const (
+	// EndIndicator closes a chain rendered with a template, so the block can be
+	// found again when it is not a list.
+	EndIndicator = "<!-- /chainlink -->"
+	// itemsIndicator starts a comment before the end indicator that keeps the
+	// items as a list, so a templated chain reads back with its nesting,
+	// checkboxes and group headings.
+	itemsIndicator = "<!-- chainlink-items"
+	templateFile   = "chainlink.tmpl"
+)
+
+// TemplateData is passed to user supplied chain templates.
+type TemplateData struct {
+	Chain    Chain
+	Items    []*TemplateItem
+	Current  *TemplateItem
+	Previous *TemplateItem
+	Next     *TemplateItem
+	// Mermaid is the mermaid graph of the chain, fenced as a code block.
+	Mermaid string
+}
+
+// TemplateItem is a chain item with everything a template might need to render it.
+type TemplateItem struct {
+	Index         int
+	Position      int
+	Depth         int
+	Number        int
+	Ref           string
+	URL           string
+	Message       string
+	Title         string
+	State         string
+	Badge         string
+	ListPoint     string
+	Line          string
+	IsCurrent     bool
+	IsAncestor    bool
+	IsPullRequest bool
+	Previous      *TemplateItem
+	Next          *TemplateItem
+}
+
+func (c Chain) templateData() TemplateData {
+	data := TemplateData{Chain: c, Mermaid: c.renderMermaid()}
+	lines := c.ItemLines()
+	for i, item := range c.Items {
+		data.Items = append(data.Items, &TemplateItem{
+			Index:         i,
+			Position:      i + 1,
+			Depth:         item.Depth,
+			Number:        item.Number,
+			Ref:           item.Ref(),
+			URL:           item.URL(),
+			Message:       item.Message,
+			Title:         item.Title,
+			State:         string(item.IssueState),
+			Badge:         item.Badge,
+			ListPoint:     c.ListPoint(i),
+			Line:          lines[i],
+			IsCurrent:     item.IsCurrent,
+			IsAncestor:    item.IsAncestor,
+			IsPullRequest: item.IsPullRequest,
+		})
+	}
+
+	for i, item := range data.Items {
+		if i > 0 {
+			item.Previous = data.Items[i-1]
+		}
+		if i < len(data.Items)-1 {
+			item.Next = data.Items[i+1]
+		}
+		if item.IsCurrent && data.Current == nil {
+			data.Current, data.Previous, data.Next = item, item.Previous, item.Next
+		}
+	}
+	return data
+}
+
+// WithTemplate renders the items of the chain with the template instead of as a list.
+func (c Chain) WithTemplate(tmpl *template.Template) Chain {
+	newChain := c
+	newChain.template = tmpl
+	return newChain
+}
+
+func (c Chain) renderTemplate() (string, error) {
+	buf := new(bytes.Buffer)
+	if err := c.template.Execute(buf, c.templateData()); err != nil {
+		return "", err
+	}
+	items := strings.Join(c.ItemLines(), "\n")
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n-->\n%s", c.renderIndicator(), bytes.TrimSpace(buf.Bytes()), itemsIndicator, items, EndIndicator), nil
+}
+
+// loadTemplate parses the template at path. If path is empty the template is
+// read from chainlink.tmpl in the gh config dir, and nil is returned if it does
+// not exist. The repo's .github/chainlink.tmpl is read with the repo config.
+func loadTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		path = filepath.Join(config.ConfigDir(), templateFile)
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(filepath.Base(path)).Parse(string(content))
+}
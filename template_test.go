@@ -0,0 +1,117 @@
+package main
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain_RenderTemplate(t *testing.T) {
+	chain := Chain{
+		Header: "## PR Chain",
+		Source: TestIssue,
+		Items: []ChainItem{
+			{ChainIssue: repoIssue(1), Message: "#1", ItemState: Numbered, Title: "First", IssueState: StateMerged},
+			{ChainIssue: repoIssue(2), Message: "#2", ItemState: Numbered, Title: "Second", IssueState: StateOpen},
+			{ChainIssue: repoIssue(3), Message: "#3", ItemState: Numbered, Title: "Third", IssueState: StateDraft},
+		},
+	}
+	tmpl := template.Must(template.New("").Parse(`
+| # | PR | State |
+|---|----|-------|
+{{- range .Items }}
+| {{ .Position }} | [{{ .Message }}]({{ .URL }}) {{ .Title }}{{ if .IsCurrent }} **(this PR)**{{ end }} | {{ .State }} |
+{{- end }}
+
+{{ with .Previous }}Previous: {{ .Message }}{{ end }}{{ with .Next }} Next: {{ .Message }}{{ end }}
+`))
+
+	expected := `## PR Chain
+<!-- chainlink generated from https://github.com/RoryQ/gh-chainlink/issues/1 hash=13f9d142 -->
+| # | PR | State |
+|---|----|-------|
+| 1 | [#1](https://github.com/RoryQ/gh-chainlink/issues/1) First | merged |
+| 2 | [#2](https://github.com/RoryQ/gh-chainlink/issues/2) Second **(this PR)** | open |
+| 3 | [#3](https://github.com/RoryQ/gh-chainlink/issues/3) Third | draft |
+
+Previous: #1 Next: #3
+<!-- chainlink-items
+1. #1 — First
+2. #2 — Second &larr; you are here
+3. #3 — Third
+-->
+<!-- /chainlink -->`
+	rendered := chain.WithTemplate(tmpl).ResetCurrent(repoIssue(2)).RenderMarkdown()
+	assert.Equal(t, expected, rendered)
+
+	t.Run("Reparse", func(t *testing.T) {
+		parsed, err := Parse(repoIssue(2), "Description\n\n"+rendered+"\n\nMore text")
+		assert.NoError(t, err)
+		assert.Equal(t, "## PR Chain", parsed.Header)
+
+		var numbers []int
+		for _, item := range parsed.Items {
+			numbers = append(numbers, item.Number)
+		}
+		assert.Equal(t, []int{1, 2, 3}, numbers)
+	})
+
+	t.Run("ReparseStructure", func(t *testing.T) {
+		nested := Chain{
+			Source: TestIssue,
+			Items: []ChainItem{
+				{ChainIssue: repoIssue(1), Message: "#1", ItemState: Checked},
+				{ChainIssue: repoIssue(2), Message: "#2", ItemState: Unchecked, Depth: 1},
+				{Message: "Rollout", ItemState: Bulleted},
+				{ChainIssue: repoIssue(3), Message: "#3", ItemState: Numbered, Depth: 1},
+			},
+		}.WithTemplate(template.Must(template.New("").Parse(`{{ range .Items }}{{ .Message }} {{ end }}`)))
+
+		parsed, err := Parse(repoIssue(2), nested.ResetCurrent(repoIssue(2)).RenderMarkdown())
+		assert.NoError(t, err)
+		for i, item := range parsed.Items {
+			want := nested.Items[i]
+			assert.Equal(t, []any{want.Depth, want.ItemState, want.Message}, []any{item.Depth, item.ItemState, item.Message})
+		}
+		assert.Len(t, parsed.Items, len(nested.Items))
+		assert.Equal(t, nested.Hash(), parsed.SyncedHash)
+		assert.Equal(t, parsed.SyncedHash, parsed.Hash(), "the chain reads back as it was synced")
+	})
+
+	t.Run("Replace", func(t *testing.T) {
+		body := "Description\n\n" + rendered + "\n\nMore text"
+		list := chain.ResetCurrent(repoIssue(2)).RenderMarkdown()
+		assert.Equal(t, "Description\n\n"+list+"\n\nMore text", ReplaceChain(body, list))
+	})
+}
+
+func TestCheckTemplate(t *testing.T) {
+	chain, err := Parse(TestIssue, "<!--chainlink-->\n1. #1\n2. #2\n3. #3")
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		template string
+		wantErr  string
+	}{
+		"RendersForEveryItem": {
+			template: `{{ with .Previous }}Previous: {{ .Message }}{{ end }}`,
+		},
+		"FailsForOneItem": {
+			// the last item has no next item
+			template: `Next: {{ .Next.Message }}`,
+			wantErr:  "error rendering template for RoryQ/gh-chainlink#3",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			tmpl := template.Must(template.New("").Parse(tt.template))
+			err := checkTemplate(chain.WithTemplate(tmpl))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+)
+
+func unlinkCommand(client *GhClient, args []string) error {
+	fs := flag.NewFlagSet("unlink", flag.ExitOnError)
+	name := fs.String("chain", "", "Name of the chain to unlink when the body has more than one.")
+	source := fs.Bool("source", false, "Also remove the chain from the body of the chain ref.")
+	opts := &syncOptions{}
+	fs.BoolVar(&opts.DryRun, "dry-run", false, "Print a diff of each body that would be updated without updating it.")
+	fs.BoolVar(&opts.JSON, "json", false, "Print one JSON record per item instead of a line.")
+	fs.Usage = func() {
+		_, _ = fmt.Fprintln(fs.Output(), "Usage: gh chainlink unlink [flags] <chain ref>")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("unlink takes one chain ref")
+	}
+
+	chain, err := loadChain(client, fs.Args(), *name)
+	if err != nil {
+		return err
+	}
+
+	_, err = removeChains(os.Stdout, client, *chain, unlinkItems(*chain, *source), *opts)
+	return err
+}
+
+// unlinkItems returns the items to remove the chain from. The source is only
+// included if includeSource is set, and is last so the chain can still be read
+// from it if removing the chain from another item fails.
+func unlinkItems(chain Chain, includeSource bool) []ChainItem {
+	var items []ChainItem
+	for _, item := range chain.Items {
+		isSeen := func(other ChainItem) bool { return other.IsSame(item.ChainIssue) }
+		if item.Number == 0 || item.IsSame(chain.Source) || slices.ContainsFunc(items, isSeen) {
+			continue
+		}
+		items = append(items, item)
+	}
+	if includeSource {
+		items = append(items, ChainItem{ChainIssue: chain.Source, Message: chain.Source.Ref()})
+	}
+	return items
+}
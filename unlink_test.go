@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnlinkItems(t *testing.T) {
+	chain, err := Parse(TestIssue, `<!--chainlink-->
+1. #2
+2. Rollout
+   - #1
+   - #3
+3. #2`)
+	assert.NoError(t, err)
+
+	tests := map[string]struct {
+		includeSource bool
+		want          []int
+	}{
+		"Items":        {want: []int{2, 3}},
+		"SourceIsLast": {includeSource: true, want: []int{2, 3, 1}},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var numbers []int
+			for _, item := range unlinkItems(*chain, tt.includeSource) {
+				numbers = append(numbers, item.Number)
+			}
+			assert.Equal(t, tt.want, numbers)
+		})
+	}
+}
+
+func TestUnlinkSource(t *testing.T) {
+	body := "# Description\nFirst PR.\n\n## PR Chain\n<!--chainlink-->\n1. #1\n2. #2\n\n<details>notes</details>\n"
+	chain, err := Parse(TestIssue, body)
+	assert.NoError(t, err)
+
+	rendered := chain.ResetCurrent(TestIssue).RenderMarkdown()
+	assert.Equal(t, "# Description\nFirst PR.\n\n<details>notes</details>\n", RemoveChain(body, rendered))
+}